@@ -1,6 +1,32 @@
 document.addEventListener('DOMContentLoaded', async () => {
+    // Single sign-on hands the session back in the fragment of the URL.
+    if (window.location.hash.length > 1) {
+      const params = new URLSearchParams(window.location.hash.slice(1));
+      history.replaceState(null, '', window.location.pathname + window.location.search);
+      await finishSSO(params);
+    }
     const token = localStorage.getItem('token');
   
+    // Links sent by email land on their own path with a one-time token.
+    if (window.location.pathname === '/reset-password') {
+      document.getElementById('auth-section').style.display = 'none';
+      document.getElementById('video-section').style.display = 'none';
+      document.getElementById('reset-password-section').style.display = 'block';
+      return;
+    }
+    if (window.location.pathname === '/verify-email') {
+      document.getElementById('auth-section').style.display = 'none';
+      document.getElementById('video-section').style.display = 'none';
+      document.getElementById('verify-email-section').style.display = 'block';
+      await verifyEmail();
+      return;
+    }
+    // Invitations are accepted by the account they were sent to, so logging
+    // in comes first.
+    if (window.location.pathname === '/accept-invitation' && token) {
+      await showInvitation();
+      return;
+    }
     if (token) {
       document.getElementById('auth-section').style.display = 'none';
       document.getElementById('video-section').style.display = 'block';
@@ -21,6 +47,11 @@ document.addEventListener('DOMContentLoaded', async () => {
     await login();
   });
   
+  document.getElementById('reset-password-form').addEventListener('submit', async (event) => {
+    event.preventDefault();
+    await resetPassword();
+  });
+  
   async function createVideoDraft() {
     const title = document.getElementById('video-title').value;
     const description = document.getElementById('video-description').value;
@@ -36,7 +67,7 @@ document.addEventListener('DOMContentLoaded', async () => {
       });
       const data = await res.json();
       if (!res.ok) {
-        throw new Error(`Failed to create video draft: ${data.error}`);
+        throw new Error(`Failed to create video draft: ${data.detail}`);
       }
   
       const videoID = data.id;
@@ -61,13 +92,21 @@ document.addEventListener('DOMContentLoaded', async () => {
         },
         body: JSON.stringify({ email, password }),
       });
-      const data = await res.json();
+      let data = await res.json();
       if (!res.ok) {
-        throw new Error(`Failed to login: ${data.error}`);
+        throw new Error(`Failed to login: ${data.detail}`);
+      }
+      if (data.mfa_required) {
+        data = await loginMFA(data.mfa_token);
+        if (!data) return;
       }
   
       if (data.token) {
         localStorage.setItem('token', data.token);
+        if (window.location.pathname === '/accept-invitation') {
+          await showInvitation();
+          return;
+        }
         document.getElementById('auth-section').style.display = 'none';
         document.getElementById('video-section').style.display = 'block';
         await getVideos();
@@ -79,6 +118,49 @@ document.addEventListener('DOMContentLoaded', async () => {
     }
   }
   
+  // loginMFA redeems the challenge of an account with two-factor
+  // authentication. Challenges are single use: a wrong code means starting over.
+  async function loginMFA(mfaToken) {
+    const code = prompt('Enter the code from your authenticator app, or a recovery code:');
+    if (!code) return null;
+  
+    const body = /^\d{6}$/.test(code.replace(/\s/g, ''))
+      ? { mfa_token: mfaToken, code }
+      : { mfa_token: mfaToken, recovery_code: code };
+    const res = await fetch('/api/login/mfa', {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+      },
+      body: JSON.stringify(body),
+    });
+    const data = await res.json();
+    if (!res.ok) {
+      throw new Error(`Failed to login: ${data.detail}`);
+    }
+    return data;
+  }
+  
+  // finishSSO completes a login started at the identity provider, which may
+  // still need the second factor of the account.
+  async function finishSSO(params) {
+    try {
+      if (params.has('error')) {
+        throw new Error(`Failed to login: ${params.get('error_description') || params.get('error')}`);
+      }
+      let data = { token: params.get('token') };
+      if (params.has('mfa_token')) {
+        data = await loginMFA(params.get('mfa_token'));
+        if (!data) return;
+      }
+      if (data.token) {
+        localStorage.setItem('token', data.token);
+      }
+    } catch (error) {
+      alert(`Error: ${error.message}`);
+    }
+  }
+  
   async function signup() {
     const email = document.getElementById('email').value;
     const password = document.getElementById('password').value;
@@ -93,7 +175,7 @@ document.addEventListener('DOMContentLoaded', async () => {
       });
       if (!res.ok) {
         const data = await res.json();
-        throw new Error(`Failed to create user: ${data.error}`);
+        throw new Error(`Failed to create user: ${data.detail}`);
       }
       console.log('User created!');
       await login();
@@ -102,6 +184,106 @@ document.addEventListener('DOMContentLoaded', async () => {
     }
   }
   
+  async function forgotPassword() {
+    const email = document.getElementById('email').value;
+    if (!email) {
+      alert('Enter your email first.');
+      return;
+    }
+  
+    try {
+      const res = await fetch('/api/password/forgot', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ email }),
+      });
+      if (!res.ok) {
+        const data = await res.json();
+        throw new Error(`Failed to request a password reset: ${data.detail}`);
+      }
+      alert('If an account exists for this email, a reset link is on its way.');
+    } catch (error) {
+      alert(`Error: ${error.message}`);
+    }
+  }
+  
+  async function verifyEmail() {
+    const token = new URLSearchParams(window.location.search).get('token');
+    const status = document.getElementById('verify-email-status');
+  
+    try {
+      const res = await fetch('/api/email/verify', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ token }),
+      });
+      if (!res.ok) {
+        const data = await res.json();
+        throw new Error(data.detail);
+      }
+      status.textContent = 'Your email address is verified.';
+    } catch (error) {
+      status.textContent = `Failed to verify your email address: ${error.message}`;
+    }
+  }
+  
+  async function showInvitation() {
+    document.getElementById('auth-section').style.display = 'none';
+    document.getElementById('video-section').style.display = 'none';
+    document.getElementById('accept-invitation-section').style.display = 'block';
+    await acceptInvitation();
+  }
+  
+  async function acceptInvitation() {
+    const token = new URLSearchParams(window.location.search).get('token');
+    const status = document.getElementById('accept-invitation-status');
+  
+    try {
+      const res = await fetch('/api/invitations/accept', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          Authorization: `Bearer ${localStorage.getItem('token')}`,
+        },
+        body: JSON.stringify({ token }),
+      });
+      if (!res.ok) {
+        const data = await res.json();
+        throw new Error(data.detail);
+      }
+      status.textContent = 'You joined the organization.';
+    } catch (error) {
+      status.textContent = `Failed to accept the invitation: ${error.message}`;
+    }
+  }
+  
+  async function resetPassword() {
+    const token = new URLSearchParams(window.location.search).get('token');
+    const password = document.getElementById('new-password').value;
+  
+    try {
+      const res = await fetch('/api/password/reset', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ token, password }),
+      });
+      if (!res.ok) {
+        const data = await res.json();
+        throw new Error(`Failed to reset password: ${data.detail}`);
+      }
+      alert('Password changed, you can log in now.');
+      window.location.replace('/');
+    } catch (error) {
+      alert(`Error: ${error.message}`);
+    }
+  }
+  
   function logout() {
     localStorage.removeItem('token');
     document.getElementById('auth-section').style.display = 'block';
@@ -139,7 +321,7 @@ document.addEventListener('DOMContentLoaded', async () => {
       });
       if (!res.ok) {
         const data = await res.json();
-        throw new Error(`Failed to upload thumbnail. Error: ${data.error}`);
+        throw new Error(`Failed to upload thumbnail. Error: ${data.detail}`);
       }
   
       await res.json();
@@ -172,7 +354,7 @@ document.addEventListener('DOMContentLoaded', async () => {
       });
       if (!res.ok) {
         const data = await res.json();
-        throw new Error(`Failed to upload video file. Error: ${data.error}`);
+        throw new Error(`Failed to upload video file. Error: ${data.detail}`);
       }
   
       console.log('Video uploaded!');
@@ -196,7 +378,7 @@ document.addEventListener('DOMContentLoaded', async () => {
       });
       if (!res.ok) {
         const data = await res.json();
-        throw new Error(`Failed to get videos. Error: ${data.error}`);
+        throw new Error(`Failed to get videos. Error: ${data.detail}`);
       }
   
       const videos = await res.json();
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/charlesaraya/video-manager-go/internal/auth/oidctest"
+)
+
+func main() {
+	port := flag.String("port", "9090", "port to listen on")
+	clientID := flag.String("client-id", "video-manager", "accepted client id")
+	clientSecret := flag.String("client-secret", "secret", "accepted client secret")
+	email := flag.String("email", "dev@example.com", "email of the user logged in when no login_hint is given")
+	flag.Parse()
+
+	issuer := fmt.Sprintf("http://localhost:%s", *port)
+	provider, err := oidctest.NewProvider(issuer, *clientID, *clientSecret, *email)
+	if err != nil {
+		log.Fatal(fmt.Errorf("error creating mock provider: %w", err))
+	}
+	log.Printf("Mock OIDC issuer: %s\n", issuer)
+	log.Fatal(http.ListenAndServe(":"+*port, provider.Handler()))
+}
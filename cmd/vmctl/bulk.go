@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charlesaraya/video-manager-go/pkg/client"
+)
+
+// entry is a video of a bulk upload. File and Thumbnail are relative to the
+// uploaded directory.
+type entry struct {
+	File        string `json:"file"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Thumbnail   string `json:"thumbnail"`
+}
+
+func runBulk(ctx context.Context, env *env, args []string) error {
+	flags := newFlags("bulk")
+	manifestPath := flags.String("manifest", "", "CSV or JSON manifest of titles and descriptions, DIR/manifest.csv or DIR/manifest.json by default")
+	orgID := flags.String("org", "", "organization of the videos")
+	keepGoing := flags.Bool("keep-going", false, "upload the remaining videos after a failure")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	dir := flags.Arg(0)
+	entries, err := bulkEntries(dir, *manifestPath)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no videos to upload in %s", dir)
+	}
+	c, err := env.session()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, e := range entries {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(entries), e.Title)
+		videoID, err := uploadEntry(ctx, c, dir, e, *orgID)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", e.File, err)
+			if !*keepGoing || ctx.Err() != nil {
+				return err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		fmt.Printf("%s\t%s\n", videoID, e.File)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d uploads failed:\n%w", len(errs), len(entries), errors.Join(errs...))
+	}
+	return nil
+}
+
+func uploadEntry(ctx context.Context, c *client.Client, dir string, e entry, orgID string) (string, error) {
+	video, err := c.CreateVideo(ctx, client.VideoDraft{
+		Title:          e.Title,
+		Description:    e.Description,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		return "", err
+	}
+	if e.Thumbnail != "" {
+		if err := uploadThumbnail(ctx, c, video.ID, filepath.Join(dir, e.Thumbnail)); err != nil {
+			return video.ID, err
+		}
+	}
+	return video.ID, uploadVideo(ctx, c, video.ID, filepath.Join(dir, e.File))
+}
+
+// bulkEntries returns the videos to upload from dir. Without a manifest
+// every MP4 file is uploaded and titled after its name.
+func bulkEntries(dir, manifestPath string) ([]entry, error) {
+	if manifestPath == "" {
+		for _, name := range []string{"manifest.csv", "manifest.json"} {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				manifestPath = path
+				break
+			}
+		}
+	}
+	if manifestPath == "" {
+		return scanVideos(dir)
+	}
+	entries, err := readManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range entries {
+		if e.File == "" {
+			return nil, fmt.Errorf("manifest %s: entry %d has no file", manifestPath, i+1)
+		}
+		if e.Title == "" {
+			entries[i].Title = titleFromFilename(e.File)
+		}
+	}
+	return entries, nil
+}
+
+func scanVideos(dir string) ([]entry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []entry
+	for _, file := range files {
+		if file.IsDir() || !strings.EqualFold(filepath.Ext(file.Name()), ".mp4") {
+			continue
+		}
+		entries = append(entries, entry{File: file.Name(), Title: titleFromFilename(file.Name())})
+	}
+	return entries, nil
+}
+
+func readManifest(path string) ([]entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("manifest %s doesn't exist", path)
+		}
+		return nil, err
+	}
+	defer file.Close()
+	var entries []entry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(file).Decode(&entries)
+	case ".csv":
+		entries, err = readCSVManifest(file)
+	default:
+		return nil, fmt.Errorf("manifest %s isn't a .csv or .json file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// readCSVManifest reads a CSV file whose header names the columns: file,
+// title, description and thumbnail. Only file is required.
+func readCSVManifest(r io.Reader) ([]entry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	if !slices.Contains(header, "file") {
+		return nil, errors.New(`the header has no "file" column`)
+	}
+	var entries []entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var e entry
+		for i, column := range header {
+			switch column {
+			case "file":
+				e.File = record[i]
+			case "title":
+				e.Title = record[i]
+			case "description":
+				e.Description = record[i]
+			case "thumbnail":
+				e.Thumbnail = record[i]
+			}
+		}
+		entries = append(entries, e)
+	}
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charlesaraya/video-manager-go/pkg/client"
+	"golang.org/x/term"
+)
+
+func runLogin(ctx context.Context, env *env, args []string) error {
+	flags := newFlags("login")
+	server := flags.String("server", env.settings.Server, "base URL of the video manager")
+	email := flags.String("email", "", "account email")
+	password := flags.String("password", "", "account password, prompted for when empty (VMCTL_PASSWORD)")
+	code := flags.String("code", "", "two-factor authentication code, prompted for when needed")
+	recovery := flags.Bool("recovery", false, "the code is a recovery code")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *server == "" || *email == "" {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	if *password == "" {
+		*password = os.Getenv("VMCTL_PASSWORD")
+	}
+	if *password == "" {
+		var err error
+		if *password, err = prompt("Password: ", true); err != nil {
+			return err
+		}
+	}
+
+	env.settings = settings{Server: *server}
+	c, err := env.client()
+	if err != nil {
+		return err
+	}
+	session, err := c.Login(ctx, *email, *password)
+	var mfaErr *client.MFARequiredError
+	if errors.As(err, &mfaErr) {
+		if *code == "" {
+			if *code, err = prompt("Authentication code: ", false); err != nil {
+				return err
+			}
+		}
+		session, err = c.LoginMFA(ctx, mfaErr.MFAToken, *code, *recovery)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Logged in to %s as %s\n", *server, session.User.Email)
+	return nil
+}
+
+// prompt reads a line from stdin, without echoing it when hidden and stdin
+// is a terminal.
+func prompt(label string, hidden bool) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	fd := int(os.Stdin.Fd())
+	if hidden && term.IsTerminal(fd) {
+		line, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(line), err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read %s: %w", strings.ToLower(strings.TrimSuffix(label, ": ")), err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func runLogout(ctx context.Context, env *env, args []string) error {
+	if err := newFlags("logout").Parse(args); err != nil {
+		return err
+	}
+	c, err := env.session()
+	if err != nil {
+		return err
+	}
+	// The session is forgotten even when the server can't be reached.
+	logoutErr := c.Logout(ctx)
+	env.settings.Tokens = client.Tokens{}
+	if err := env.save(); err != nil {
+		return err
+	}
+	return logoutErr
+}
+
+func runWhoami(ctx context.Context, env *env, args []string) error {
+	if err := newFlags("whoami").Parse(args); err != nil {
+		return err
+	}
+	c, err := env.session()
+	if err != nil {
+		return err
+	}
+	user, err := c.CurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s (%s) on %s\n", user.Email, user.Role, env.settings.Server)
+	return nil
+}
+
+func runCreate(ctx context.Context, env *env, args []string) error {
+	flags := newFlags("create")
+	var draft client.VideoDraft
+	flags.StringVar(&draft.Title, "title", "", "title of the video")
+	flags.StringVar(&draft.Description, "description", "", "description of the video")
+	flags.StringVar(&draft.OrganizationID, "org", "", "organization of the video, the personal one by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if draft.Title == "" || flags.NArg() > 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	c, err := env.session()
+	if err != nil {
+		return err
+	}
+	video, err := c.CreateVideo(ctx, draft)
+	if err != nil {
+		return err
+	}
+	fmt.Println(video.ID)
+	return nil
+}
+
+func runUpload(ctx context.Context, env *env, args []string) error {
+	flags := newFlags("upload")
+	videoID := flags.String("id", "", "video to upload to, a new one is created when empty")
+	var draft client.VideoDraft
+	flags.StringVar(&draft.Title, "title", "", "title of the new video, the file name by default")
+	flags.StringVar(&draft.Description, "description", "", "description of the new video")
+	flags.StringVar(&draft.OrganizationID, "org", "", "organization of the new video")
+	thumbnail := flags.String("thumbnail", "", "PNG or JPEG thumbnail to upload too")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	c, err := env.session()
+	if err != nil {
+		return err
+	}
+	if *videoID == "" {
+		if draft.Title == "" {
+			draft.Title = titleFromFilename(flags.Arg(0))
+		}
+		video, err := c.CreateVideo(ctx, draft)
+		if err != nil {
+			return err
+		}
+		*videoID = video.ID
+	}
+	if *thumbnail != "" {
+		if err := uploadThumbnail(ctx, c, *videoID, *thumbnail); err != nil {
+			return err
+		}
+	}
+	if err := uploadVideo(ctx, c, *videoID, flags.Arg(0)); err != nil {
+		return err
+	}
+	fmt.Println(*videoID)
+	return nil
+}
+
+func runThumbnail(ctx context.Context, env *env, args []string) error {
+	flags := newFlags("thumbnail")
+	videoID := flags.String("id", "", "video of the thumbnail")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *videoID == "" || flags.NArg() != 1 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	c, err := env.session()
+	if err != nil {
+		return err
+	}
+	return uploadThumbnail(ctx, c, *videoID, flags.Arg(0))
+}
+
+func uploadVideo(ctx context.Context, c *client.Client, videoID, path string) error {
+	return uploadFile(path, func(p *progress) error {
+		_, err := c.UploadVideo(ctx, videoID, filepath.Base(path), p)
+		return err
+	})
+}
+
+func uploadThumbnail(ctx context.Context, c *client.Client, videoID, path string) error {
+	mediaType, err := imageMediaType(path)
+	if err != nil {
+		return err
+	}
+	return uploadFile(path, func(p *progress) error {
+		_, err := c.UploadThumbnail(ctx, videoID, filepath.Base(path), mediaType, p)
+		return err
+	})
+}
+
+// uploadFile opens path and hands it to upload behind a progress bar.
+func uploadFile(path string, upload func(*progress) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	p := newProgress(file, filepath.Base(path), info.Size())
+	err = upload(p)
+	p.done(err)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", path, err)
+	}
+	return nil
+}
+
+func imageMediaType(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return client.MediaTypePNG, nil
+	case ".jpg", ".jpeg":
+		return client.MediaTypeJPEG, nil
+	}
+	return "", fmt.Errorf("thumbnail %s isn't a PNG or JPEG image", path)
+}
+
+func titleFromFilename(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+func runList(ctx context.Context, env *env, args []string) error {
+	flags := newFlags("list")
+	orgID := flags.String("org", "", "organization to list, all of them by default")
+	asJSON := flags.Bool("json", false, "print the videos as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	return listVideos(ctx, env, *orgID, "", *asJSON)
+}
+
+func runSearch(ctx context.Context, env *env, args []string) error {
+	flags := newFlags("search")
+	orgID := flags.String("org", "", "organization to search, all of them by default")
+	asJSON := flags.Bool("json", false, "print the videos as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	return listVideos(ctx, env, *orgID, strings.Join(flags.Args(), " "), *asJSON)
+}
+
+// listVideos prints the videos whose title or description contain query,
+// ignoring case. The API has no search, the videos are filtered here.
+func listVideos(ctx context.Context, env *env, orgID, query string, asJSON bool) error {
+	c, err := env.session()
+	if err != nil {
+		return err
+	}
+	videos, err := c.ListVideos(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if query != "" {
+		query = strings.ToLower(query)
+		matches := videos[:0]
+		for _, video := range videos {
+			if strings.Contains(strings.ToLower(video.Title), query) ||
+				strings.Contains(strings.ToLower(video.Description), query) {
+				matches = append(matches, video)
+			}
+		}
+		videos = matches
+	}
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if videos == nil {
+			videos = []client.Video{}
+		}
+		return encoder.Encode(videos)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTITLE\tCREATED\tVIDEO\tTHUMBNAIL")
+	for _, video := range videos {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			video.ID, video.Title, video.CreatedAt.Local().Format("2006-01-02 15:04"),
+			yesNo(video.VideoURL != ""), yesNo(video.ThumbnailURL != ""))
+	}
+	return w.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func runDelete(ctx context.Context, env *env, args []string) error {
+	flags := newFlags("delete")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	c, err := env.session()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, videoID := range flags.Args() {
+		if err := c.DeleteVideo(ctx, videoID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", videoID, err))
+			continue
+		}
+		fmt.Println("deleted", videoID)
+	}
+	return errors.Join(errs...)
+}
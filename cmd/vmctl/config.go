@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/charlesaraya/video-manager-go/pkg/client"
+)
+
+// settings is what the config file holds: the server and the session tokens.
+type settings struct {
+	Server string `json:"server"`
+	client.Tokens
+}
+
+type env struct {
+	path     string
+	settings settings
+}
+
+func loadEnv(path string) (*env, error) {
+	e := &env{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return e, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := json.Unmarshal(data, &e.settings); err != nil {
+		return nil, fmt.Errorf("failed to decode config %s: %w", path, err)
+	}
+	return e, nil
+}
+
+// save writes the config file, readable by the user only since it holds the
+// refresh token.
+func (e *env) save() error {
+	data, err := json.MarshalIndent(e.settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(e.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmp := e.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := os.Rename(tmp, e.path); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// client returns an API client of the configured server. Tokens refreshed
+// during the call are saved so the next command reuses them.
+func (e *env) client() (*client.Client, error) {
+	if e.settings.Server == "" {
+		return nil, errors.New("no server configured, run vmctl login first")
+	}
+	c := client.New(e.settings.Server, nil)
+	c.SetTokens(e.settings.Tokens)
+	c.OnTokens = func(tokens client.Tokens) {
+		e.settings.Tokens = tokens
+		if err := e.save(); err != nil {
+			fmt.Fprintln(os.Stderr, "vmctl:", err)
+		}
+	}
+	return c, nil
+}
+
+// session is like client, for commands that need to be logged in.
+func (e *env) session() (*client.Client, error) {
+	if e.settings.RefreshToken == "" {
+		return nil, errors.New("not logged in, run vmctl login first")
+	}
+	return e.client()
+}
@@ -0,0 +1,107 @@
+// Command vmctl scripts the video manager API from the command line:
+//
+//	vmctl login -server http://localhost:8080 -email me@example.com
+//	vmctl upload -title "Demo" -thumbnail demo.png demo.mp4
+//	vmctl bulk -manifest videos.csv ./videos
+//
+// The session is kept in a config file and refreshed automatically.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, env *env, args []string) error
+}
+
+// commands is filled in init since the commands refer to it for their usage.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"login":     {"login -server URL -email EMAIL [-password PASSWORD] [-code CODE]", runLogin},
+		"logout":    {"logout", runLogout},
+		"whoami":    {"whoami", runWhoami},
+		"create":    {"create -title TITLE [-description TEXT] [-org ID]", runCreate},
+		"upload":    {"upload [-id ID | -title TITLE [-description TEXT] [-org ID]] [-thumbnail IMAGE] VIDEO", runUpload},
+		"thumbnail": {"thumbnail -id ID IMAGE", runThumbnail},
+		"list":      {"list [-org ID] [-json]", runList},
+		"search":    {"search [-org ID] [-json] QUERY", runSearch},
+		"delete":    {"delete ID...", runDelete},
+		"bulk":      {"bulk [-manifest FILE] [-org ID] [-keep-going] DIR", runBulk},
+	}
+}
+
+func main() {
+	flags := flag.NewFlagSet("vmctl", flag.ExitOnError)
+	configPath := flags.String("config", defaultConfigPath(), "file the server and session are stored in (VMCTL_CONFIG)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: vmctl [-config FILE] COMMAND [ARGS]\n\ncommands:")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(flags.Output(), "  "+commands[name].usage)
+		}
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "vmctl: unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	env, err := loadEnv(*configPath)
+	if err == nil {
+		err = cmd.run(ctx, env, flags.Args()[1:])
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "vmctl:", err)
+		os.Exit(1)
+	}
+}
+
+func defaultConfigPath() string {
+	if path := os.Getenv("VMCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".vmctl.json"
+	}
+	return filepath.Join(dir, "vmctl", "config.json")
+}
+
+// newFlags returns the flag set of a command, its errors are returned rather
+// than exiting.
+func newFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("vmctl "+name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: vmctl "+commands[name].usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
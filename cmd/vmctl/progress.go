@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const progressWidth = 30
+
+// progress reports how much of an upload has been read. On a terminal it
+// draws a bar on stderr, otherwise it only prints the outcome.
+type progress struct {
+	r     io.Reader
+	label string
+	total int64
+
+	mu      sync.Mutex
+	read    int64
+	drawn   time.Time
+	enabled bool
+}
+
+func newProgress(r io.Reader, label string, total int64) *progress {
+	return &progress{
+		r:       r,
+		label:   label,
+		total:   total,
+		enabled: term.IsTerminal(int(os.Stderr.Fd())),
+	}
+}
+
+func (p *progress) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.read += int64(n)
+	// Redraws at most ten times a second.
+	if p.enabled && time.Since(p.drawn) >= 100*time.Millisecond {
+		p.draw()
+		p.drawn = time.Now()
+	}
+	return n, err
+}
+
+func (p *progress) draw() {
+	ratio := 1.0
+	if p.total > 0 {
+		ratio = min(float64(p.read)/float64(p.total), 1)
+	}
+	filled := int(ratio * progressWidth)
+	fmt.Fprintf(os.Stderr, "\r%-24.24s [%s%s] %3.0f%% %s/%s",
+		p.label,
+		strings.Repeat("=", filled), strings.Repeat(" ", progressWidth-filled),
+		ratio*100, formatBytes(p.read), formatBytes(p.total))
+}
+
+// done ends the line of the bar with the outcome of the upload.
+func (p *progress) done(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.enabled {
+		p.draw()
+		fmt.Fprint(os.Stderr, " ")
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: %s ", p.label, formatBytes(p.read))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed")
+		return
+	}
+	fmt.Fprintln(os.Stderr, "done")
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/charlesaraya/video-manager-go/internal/database"
+)
+
+const (
+	AuditLogin              string = "auth.login"
+	AuditLoginFailed        string = "auth.login_failed"
+	AuditTokenRevoked       string = "auth.token_revoked"
+	AuditPasswordReset      string = "auth.password_reset"
+	AuditPasswordChanged    string = "auth.password_changed"
+	AuditEmailChanged       string = "auth.email_changed"
+	AuditMFAEnabled         string = "auth.mfa_enabled"
+	AuditMFADisabled        string = "auth.mfa_disabled"
+	AuditVideoCreated       string = "video.created"
+	AuditVideoUpdated       string = "video.updated"
+	AuditVideoUploaded      string = "video.uploaded"
+	AuditVideoDeleted       string = "video.deleted"
+	AuditMemberInvited      string = "organization.member_invited"
+	AuditMemberJoined       string = "organization.member_joined"
+	AuditMemberRoleChanged  string = "organization.member_role_changed"
+	AuditMemberRemoved      string = "organization.member_removed"
+	AuditAdminUserDisabled  string = "admin.user_disabled"
+	AuditAdminUserEnabled   string = "admin.user_enabled"
+	AuditAdminUserDeleted   string = "admin.user_deleted"
+	AuditAdminImpersonated  string = "admin.user_impersonated"
+	AuditAdminVideoTakedown string = "admin.video_takedown"
+	AuditAdminReset         string = "admin.reset"
+	AuditWebhookCreated     string = "webhook.created"
+	AuditWebhookUpdated     string = "webhook.updated"
+	AuditWebhookDeleted     string = "webhook.deleted"
+	AuditTargetUser         string = "user"
+	AuditTargetVideo        string = "video"
+	AuditTargetOrganization string = "organization"
+	AuditTargetEmail        string = "email"
+	AuditTargetWebhook      string = "webhook"
+)
+
+// auditEvent is a single entry of the audit log. Diff is marshalled to JSON
+// and usually maps field names to auditChange values.
+type auditEvent struct {
+	Action     string
+	ActorID    string
+	TargetType string
+	TargetID   string
+	Diff       any
+}
+
+type auditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// recordAudit appends event to the audit log with q, the transaction of the
+// change it records, so that neither is kept without the other. The admin
+// acting is recorded too if the request impersonates its user.
+func recordAudit(req *http.Request, cfg *Config, q database.Querier, event auditEvent) error {
+	diff := []byte("{}")
+	if event.Diff != nil {
+		data, err := json.Marshal(event.Diff)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit diff: %w", err)
+		}
+		diff = data
+	}
+	impersonatorID := requestImpersonator(req.Context())
+	params := database.CreateAuditEventParams{
+		Action:         event.Action,
+		ActorID:        sql.NullString{String: event.ActorID, Valid: event.ActorID != ""},
+		ImpersonatorID: sql.NullString{String: impersonatorID, Valid: impersonatorID != ""},
+		Ip:             clientIP(cfg, req),
+		TargetType:     event.TargetType,
+		TargetID:       event.TargetID,
+		Diff:           string(diff),
+	}
+	if err := q.CreateAuditEvent(req.Context(), params); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// recordAttempt appends event to the audit log for an attempt that changed
+// nothing, such as a failed login. Failing to record it never fails the
+// request it belongs to, it's only logged.
+func recordAttempt(req *http.Request, cfg *Config, event auditEvent) {
+	if err := recordAudit(req, cfg, cfg.DB, event); err != nil {
+		slog.ErrorContext(req.Context(), "failed to record audit event", "action", event.Action, "error", err)
+	}
+}
+
+// recordLogin records a successful login of user with the given method.
+func recordLogin(req *http.Request, cfg *Config, q database.Querier, user database.User, method string) error {
+	return recordAudit(req, cfg, q, auditEvent{
+		Action:     AuditLogin,
+		ActorID:    user.ID,
+		TargetType: AuditTargetUser,
+		TargetID:   user.ID,
+		Diff:       map[string]string{"method": method},
+	})
+}
@@ -2,16 +2,24 @@ package api
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/config"
 	"github.com/charlesaraya/video-manager-go/internal/database"
-	"github.com/joho/godotenv"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/charlesaraya/video-manager-go/internal/events"
+	"github.com/charlesaraya/video-manager-go/internal/mailer"
+	"github.com/charlesaraya/video-manager-go/internal/migrations"
+	"github.com/charlesaraya/video-manager-go/internal/ratelimit"
+	"github.com/charlesaraya/video-manager-go/internal/storage"
 )
 
 const (
@@ -21,92 +29,226 @@ const (
 	MimeTypeVideo     string = "video/mp4"
 	MimeTypeAudio     string = "audio/mp3"
 	MimeTypeText      string = "text/html"
+	MailerBackendSMTP string = config.MailerBackendSMTP
+	MailerBackendLog  string = config.MailerBackendLog
+
+	LoginThrottleBaseDelay time.Duration = time.Second
+	// LocalStoragePath is where the app serves videos kept by the local
+	// storage backend.
+	LocalStoragePath string = "/media/"
 )
 
 type Config struct {
-	DB                   *database.Queries
-	Platform             string
-	TokenSecret          string
-	Port                 string
-	AssetsDirPath        string
-	AssetsBrowserURL     string
-	AppDirPath           string
-	S3BucketName         string
-	S3BucketRegion       string
-	S3URLExpirationLimit string
-	S3CfDistribution     string
-	S3Client             *s3.Client
+	DB                         database.Querier
+	DBConn                     DBConn
+	InTx                       func(ctx context.Context, fn func(q database.Querier) error) error
+	Platform                   string
+	TokenKeys                  *auth.KeySet
+	TOTPKey                    []byte
+	Port                       string
+	AssetsDirPath              string
+	AssetsBrowserURL           string
+	AppDirPath                 string
+	Storage                    storage.Blobs
+	AppBaseURL                 string
+	Mailer                     mailer.Mailer
+	RequireVerifiedUploads     bool
+	MaxVideoUploadSize         int64
+	MaxThumbnailUploadSize     int64
+	TrustProxyHeaders          bool
+	AuthLimiter                *ratelimit.Limiter
+	UploadLimiter              *ratelimit.Limiter
+	ReadLimiter                *ratelimit.Limiter
+	LoginThrottle              *ratelimit.Throttle
+	AccountThrottle            *ratelimit.Throttle
+	OIDC                       *auth.OIDCProvider
+	AccountDeletionGracePeriod time.Duration
+	WebhookClient              *http.Client
+	WebhookMaxAttempts         int
+	WebhookRetryDelay          time.Duration
+	WebhookPollInterval        time.Duration
+	EventSinks                 []events.Sink
+	EventPublishTimeout        time.Duration
+	EventRetryDelay            time.Duration
+	EventPollInterval          time.Duration
+	EventRetention             time.Duration
+	ShutdownTimeout            time.Duration
+	ReadHeaderTimeout          time.Duration
+	IdleTimeout                time.Duration
+	ReadinessDrainDelay        time.Duration
+	ShuttingDown               atomic.Bool
+	Background                 sync.WaitGroup
+	Logger                     *slog.Logger
+	TracingExporter            string
+	ServiceName                string
 }
 
-func LoadConfig() (*Config, error) {
-	err := godotenv.Load()
+// LoadConfig sets up everything the handlers need from validated settings:
+// it opens and migrates the database and connects the optional subsystems
+// that are turned on.
+func LoadConfig(settings *config.Config) (*Config, error) {
+	logger, err := NewLogger(settings.Log.Format, settings.Log.Level)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load .env file: %w", err)
-	}
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		return nil, fmt.Errorf("failed to set DB_PATH environment variable")
+		return nil, fmt.Errorf("failed to configure logging: %w", err)
 	}
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := OpenDatabase(settings.Database)
 	if err != nil {
-		return nil, errors.New("error opening the database")
+		return nil, err
 	}
-	platform := os.Getenv("PLATFORM")
-	if platform == "" {
-		return nil, fmt.Errorf("failed to set PLATFORM environment variable")
+	if settings.Database.AutoMigrate {
+		applied, err := migrations.Up(context.Background(), db.Driver, db.Primary)
+		for _, version := range applied {
+			logger.Info("applied database migration", "version", version)
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
-	tokenSecret := os.Getenv("TOKEN_SECRET")
-	if tokenSecret == "" {
-		return nil, fmt.Errorf("failed to set TOKEN_SECRET environment variable")
+	if err := migrations.CheckVersion(context.Background(), db.Driver, db.Primary); err != nil {
+		return nil, err
 	}
-	port := os.Getenv("PORT")
-	if port == "" {
-		return nil, fmt.Errorf("failed to set PORT environment variable")
+	tokenKeys, err := auth.LoadKeySet(settings.Tokens.KeysDir, settings.Tokens.ActiveKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token keys: %w", err)
 	}
-	appDirPath := os.Getenv("APP_DIR_PATH")
-	if appDirPath == "" {
-		return nil, fmt.Errorf("failed to set APP_DIR_PATH environment variable")
+	totpKey, err := settings.Tokens.DecodeTOTPKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode totp key: %w", err)
 	}
-	assetsDirPath := os.Getenv("ASSETS_DIR_PATH")
-	if assetsDirPath == "" {
-		return nil, fmt.Errorf("failed to set ASSETS_DIR_PATH environment variable")
+	appBaseURL := strings.TrimSuffix(settings.Server.BaseURL, "/")
+	blobs, err := loadStorage(settings, appBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	mailBackend, err := loadMailer(settings.Mail)
+	if err != nil {
+		return nil, err
 	}
-	assetsBrowserURL := os.Getenv("ASSETS_BROWSER_URL")
-	if assetsBrowserURL == "" {
-		return nil, fmt.Errorf("failed to set ASSETS_BROWSER_URL environment variable")
+	authLimiter, err := ratelimit.ParseLimiter(settings.RateLimits.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse auth rate limit: %w", err)
 	}
-	s3BucketName := os.Getenv("S3_BUCKET_NAME")
-	if s3BucketName == "" {
-		return nil, fmt.Errorf("failed to set S3_BUCKET environment variable")
+	uploadLimiter, err := ratelimit.ParseLimiter(settings.RateLimits.Uploads)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse uploads rate limit: %w", err)
 	}
-	s3BucketRegion := os.Getenv("S3_BUCKET_REGION")
-	if s3BucketRegion == "" {
-		return nil, fmt.Errorf("failed to set S3_BUCKET_REGION environment variable")
+	readLimiter, err := ratelimit.ParseLimiter(settings.RateLimits.Reads)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse reads rate limit: %w", err)
 	}
-	s3CfDistribution := os.Getenv("S3_CF_DISTRIBUTION")
-	if s3CfDistribution == "" {
-		return nil, fmt.Errorf("failed to set S3_CF_DISTRIBUTION environment variable")
+	oidcProvider, err := loadOIDCProvider(settings.OIDC, appBaseURL)
+	if err != nil {
+		return nil, err
 	}
-	s3URLExpirationLimit := os.Getenv("S3_URL_EXPIRATION_LIMIT")
-	if s3URLExpirationLimit == "" {
-		return nil, fmt.Errorf("failed to set S3_URL_EXPIRATION_LIMIT environment variable")
+	queries := db.Querier()
+	if err := sealTOTPSecrets(context.Background(), queries, totpKey); err != nil {
+		return nil, err
 	}
-	awsSDKConfig, err := config.LoadDefaultConfig(context.Background())
+	eventSinks, err := loadEventSinks(settings.Events, queries)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load aws default config")
+		return nil, err
 	}
 	return &Config{
-		DB:                   database.New(db),
-		Platform:             platform,
-		TokenSecret:          tokenSecret,
-		Port:                 port,
-		AppDirPath:           appDirPath,
-		AssetsBrowserURL:     assetsBrowserURL,
-		AssetsDirPath:        assetsDirPath,
-		S3BucketName:         s3BucketName,
-		S3BucketRegion:       s3BucketRegion,
-		S3URLExpirationLimit: s3URLExpirationLimit,
-		S3CfDistribution:     s3CfDistribution,
-		S3Client:             s3.NewFromConfig(awsSDKConfig),
+		DB:                         queries,
+		DBConn:                     db.Conn,
+		InTx:                       db.InTx,
+		Platform:                   settings.Server.Platform,
+		TokenKeys:                  tokenKeys,
+		TOTPKey:                    totpKey,
+		Port:                       settings.Server.Port,
+		AppDirPath:                 settings.Server.AppDir,
+		AssetsBrowserURL:           settings.Server.AssetsURL,
+		AssetsDirPath:              settings.Server.AssetsDir,
+		Storage:                    blobs,
+		AppBaseURL:                 appBaseURL,
+		Mailer:                     mailBackend,
+		RequireVerifiedUploads:     settings.Uploads.RequireVerifiedEmail,
+		MaxVideoUploadSize:         int64(settings.Uploads.MaxVideoSize),
+		MaxThumbnailUploadSize:     int64(settings.Uploads.MaxThumbnailSize),
+		TrustProxyHeaders:          settings.Server.TrustProxyHeaders,
+		AuthLimiter:                authLimiter,
+		UploadLimiter:              uploadLimiter,
+		ReadLimiter:                readLimiter,
+		LoginThrottle:              ratelimit.NewThrottle(settings.RateLimits.LoginMaxFailures, LoginThrottleBaseDelay, time.Duration(settings.RateLimits.LoginLockout)),
+		AccountThrottle:            ratelimit.NewThrottle(settings.RateLimits.AccountMaxFailures, LoginThrottleBaseDelay, time.Duration(settings.RateLimits.LoginLockout)),
+		OIDC:                       oidcProvider,
+		AccountDeletionGracePeriod: time.Duration(settings.Accounts.DeletionGracePeriod),
+		WebhookClient:              newWebhookClient(time.Duration(settings.Webhooks.Timeout)),
+		WebhookMaxAttempts:         settings.Webhooks.MaxAttempts,
+		WebhookRetryDelay:          time.Duration(settings.Webhooks.RetryDelay),
+		WebhookPollInterval:        time.Duration(settings.Webhooks.PollInterval),
+		EventSinks:                 eventSinks,
+		EventPublishTimeout:        time.Duration(settings.Events.Timeout),
+		EventRetryDelay:            time.Duration(settings.Events.RetryDelay),
+		EventPollInterval:          time.Duration(settings.Events.PollInterval),
+		EventRetention:             time.Duration(settings.Events.Retention),
+		ShutdownTimeout:            time.Duration(settings.Server.ShutdownTimeout),
+		ReadHeaderTimeout:          time.Duration(settings.Server.ReadHeaderTimeout),
+		IdleTimeout:                time.Duration(settings.Server.IdleTimeout),
+		ReadinessDrainDelay:        time.Duration(settings.Server.ReadinessDelay),
+		Logger:                     logger,
+		TracingExporter:            settings.Tracing.Exporter,
+		ServiceName:                settings.Tracing.ServiceName,
 	}, nil
 }
+
+// loadStorage only talks to AWS when videos are stored in S3.
+func loadStorage(settings *config.Config, appBaseURL string) (storage.Blobs, error) {
+	if settings.Storage.Backend == config.StorageBackendLocal {
+		return storage.NewLocal(settings.Storage.LocalDir, appBaseURL+strings.TrimSuffix(LocalStoragePath, "/"))
+	}
+	awsSDKConfig, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(settings.S3.Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws default config: %w", err)
+	}
+	return storage.NewS3(s3.NewFromConfig(awsSDKConfig), settings.S3.Bucket, settings.S3.Distribution), nil
+}
+
+// loadOIDCProvider returns nil when single sign-on isn't configured.
+func loadOIDCProvider(settings config.OIDC, appBaseURL string) (*auth.OIDCProvider, error) {
+	if settings.Issuer == "" {
+		return nil, nil
+	}
+	redirectURL := settings.RedirectURL
+	if redirectURL == "" {
+		redirectURL = appBaseURL + OIDCCallbackPath
+	}
+	provider, err := auth.NewOIDCProvider(context.Background(), settings.Issuer, settings.ClientID, settings.ClientSecret, redirectURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load oidc provider: %w", err)
+	}
+	return provider, nil
+}
+
+// loadEventSinks connects the sinks listed in the settings, in order.
+func loadEventSinks(settings config.Events, queries database.Querier) ([]events.Sink, error) {
+	var sinks []events.Sink
+	for _, name := range settings.SinkNames() {
+		var sink events.Sink
+		var err error
+		switch name {
+		case config.EventSinkWebhooks:
+			sink = webhookSink{db: queries}
+		case config.EventSinkLog:
+			sink, err = events.NewLogSink(settings.LogPath)
+		case config.EventSinkNATS:
+			sink, err = events.NewNATSSink(settings.NATSURL, settings.NATSSubject)
+		case config.EventSinkRedis:
+			sink, err = events.NewRedisSink(settings.RedisURL, settings.RedisStream)
+		default:
+			err = fmt.Errorf("unknown event sink %q", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s event sink: %w", name, err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+func loadMailer(settings config.Mail) (mailer.Mailer, error) {
+	if settings.Backend == MailerBackendSMTP {
+		return mailer.NewSMTPMailer(settings.SMTPHost, settings.SMTPPort, settings.SMTPUsername, settings.SMTPPassword, settings.SMTPFrom), nil
+	}
+	return mailer.NewLogMailer(settings.LogPath)
+}
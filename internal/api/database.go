@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/config"
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/charlesaraya/video-manager-go/internal/database/postgres"
+	"github.com/charlesaraya/video-manager-go/internal/metrics"
+	"github.com/charlesaraya/video-manager-go/internal/migrations"
+	"github.com/charlesaraya/video-manager-go/internal/sqlite"
+	"github.com/charlesaraya/video-manager-go/internal/tracing"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+const PostgresConnMaxIdleTime time.Duration = time.Minute * 5
+
+// ErrDuplicate is what InTx fails with when a write breaks a unique
+// constraint, on either backend. Handlers answer with a more specific code
+// where they know which constraint it is.
+var ErrDuplicate = NewError(ErrConflict, "already_exists", "the resource already exists")
+
+// dbSystems maps DB_DRIVER values to the db.system names traces use.
+var dbSystems = map[string]string{
+	migrations.DriverSQLite:   "sqlite",
+	migrations.DriverPostgres: "postgresql",
+}
+
+// DBConn is what queries and readiness checks run on, whichever the backend.
+type DBConn interface {
+	database.DBTX
+	PingContext(ctx context.Context) error
+	Close() error
+}
+
+type Database struct {
+	Driver string
+	Conn   DBConn
+	// Primary is the pool migrations run on. SQLite only has one connection
+	// allowed to write, Postgres uses the same pool as the queries.
+	Primary *sql.DB
+}
+
+// OpenDatabase opens the backend selected by the database settings: the
+// SQLite file at its path, or the Postgres database at its URL.
+func OpenDatabase(settings config.Database) (*Database, error) {
+	switch settings.Driver {
+	case migrations.DriverSQLite:
+		db, err := sqlite.Open(settings.Path, sqlite.Options{
+			BusyTimeout:  time.Duration(settings.BusyTimeout),
+			MaxReadConns: settings.MaxReadConns,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error opening the database: %w", err)
+		}
+		return &Database{Driver: migrations.DriverSQLite, Conn: db, Primary: db.Writer}, nil
+	case migrations.DriverPostgres:
+		db, err := sql.Open("pgx", settings.URL)
+		if err != nil {
+			return nil, fmt.Errorf("error opening the database: %w", err)
+		}
+		db.SetMaxOpenConns(settings.MaxConns)
+		db.SetMaxIdleConns(settings.MaxConns)
+		db.SetConnMaxIdleTime(PostgresConnMaxIdleTime)
+		return &Database{Driver: migrations.DriverPostgres, Conn: db, Primary: db}, nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q (%s or %s)", settings.Driver, migrations.DriverSQLite, migrations.DriverPostgres)
+	}
+}
+
+// Querier returns the instrumented queries of the backend.
+func (db *Database) Querier() database.Querier {
+	return db.querier(db.Conn)
+}
+
+func (db *Database) querier(conn database.DBTX) database.Querier {
+	conn = tracing.InstrumentDB(metrics.InstrumentDB(conn), dbSystems[db.Driver])
+	if db.Driver == migrations.DriverPostgres {
+		return postgres.NewRepository(conn)
+	}
+	return database.New(conn)
+}
+
+// InTx runs fn with queries bound to a transaction on the primary pool, which
+// is committed if fn returns nil and rolled back otherwise. On SQLite the
+// transaction holds the only write connection until it ends, so fn must not
+// use any other queries to write.
+func (db *Database) InTx(ctx context.Context, fn func(q database.Querier) error) error {
+	tx, err := db.Primary.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(db.querier(tx)); err != nil {
+		tx.Rollback()
+		return db.conflict(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return db.conflict(fmt.Errorf("failed to commit transaction: %w", err))
+	}
+	return nil
+}
+
+// conflict wraps err in ErrDuplicate if the backend rejected a write because
+// of a unique constraint.
+func (db *Database) conflict(err error) error {
+	if errors.Is(err, ErrDuplicate) {
+		return err
+	}
+	isUniqueViolation := sqlite.IsUniqueViolation
+	if db.Driver == migrations.DriverPostgres {
+		isUniqueViolation = postgres.IsUniqueViolation
+	}
+	if !isUniqueViolation(err) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrDuplicate, err)
+}
+
+func (db *Database) Close() error {
+	return db.Conn.Close()
+}
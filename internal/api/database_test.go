@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/config"
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/charlesaraya/video-manager-go/internal/migrations"
+	"github.com/google/uuid"
+)
+
+// testDatabaseURLEnv names the Postgres database the backend tests also run
+// against when set. Rows are created with fresh ids, so it can be reused.
+const testDatabaseURLEnv string = "TEST_DATABASE_URL"
+
+// openTestDatabase opens and migrates the database of settings, closed when
+// the test ends.
+func openTestDatabase(t *testing.T, settings config.Database) *Database {
+	t.Helper()
+	db, err := OpenDatabase(settings)
+	if err != nil {
+		t.Fatalf("failed to open %s database: %v", settings.Driver, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := migrations.Up(context.Background(), db.Driver, db.Primary); err != nil {
+		t.Fatalf("failed to migrate %s database: %v", settings.Driver, err)
+	}
+	return db
+}
+
+// forEachBackend runs test against a fresh SQLite database, and against the
+// Postgres one at TEST_DATABASE_URL if it's set.
+func forEachBackend(t *testing.T, test func(t *testing.T, db *Database)) {
+	t.Run(migrations.DriverSQLite, func(t *testing.T) {
+		test(t, openTestDatabase(t, config.Database{
+			Driver: migrations.DriverSQLite,
+			Path:   filepath.Join(t.TempDir(), "test.db"),
+		}))
+	})
+	t.Run(migrations.DriverPostgres, func(t *testing.T) {
+		url := os.Getenv(testDatabaseURLEnv)
+		if url == "" {
+			t.Skipf("%s is not set", testDatabaseURLEnv)
+		}
+		test(t, openTestDatabase(t, config.Database{
+			Driver:   migrations.DriverPostgres,
+			URL:      url,
+			MaxConns: 4,
+		}))
+	})
+}
+
+func createTestUser(t *testing.T, q database.Querier) database.User {
+	t.Helper()
+	id := uuid.NewString()
+	user, err := q.CreateUser(context.Background(), database.CreateUserParams{
+		ID:       id,
+		Email:    id + "@example.com",
+		Password: "hash-" + id,
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+func createTestOrganization(t *testing.T, q database.Querier, owner database.User) database.Organization {
+	t.Helper()
+	ctx := context.Background()
+	org, err := q.CreateOrganization(ctx, database.CreateOrganizationParams{ID: uuid.NewString(), Name: "Team"})
+	if err != nil {
+		t.Fatalf("failed to create organization: %v", err)
+	}
+	err = q.AddOrganizationMember(ctx, database.AddOrganizationMemberParams{
+		OrganizationID: org.ID,
+		UserID:         owner.ID,
+		Role:           OrgRoleOwner,
+	})
+	if err != nil {
+		t.Fatalf("failed to add organization owner: %v", err)
+	}
+	return org
+}
+
+func TestQuerierUsers(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db *Database) {
+		ctx := context.Background()
+		q := db.Querier()
+		user := createTestUser(t, q)
+		got, err := q.GetUserByEmail(ctx, user.Email)
+		if err != nil {
+			t.Fatalf("failed to get user by email: %v", err)
+		}
+		if got.ID != user.ID || got.EmailVerifiedAt.Valid {
+			t.Errorf("got user %+v, want unverified %s", got, user.ID)
+		}
+		if _, err := q.GetUserByID(ctx, uuid.NewString()); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("getting a missing user: got error %v, want %v", err, sql.ErrNoRows)
+		}
+		// Verification only applies to the address it was sent to.
+		rows, err := q.MarkUserEmailVerified(ctx, database.MarkUserEmailVerifiedParams{ID: user.ID, Email: "other@example.com"})
+		if err != nil || rows != 0 {
+			t.Errorf("verifying another email: got %d rows, error %v, want 0 rows", rows, err)
+		}
+		rows, err = q.MarkUserEmailVerified(ctx, database.MarkUserEmailVerifiedParams{ID: user.ID, Email: user.Email})
+		if err != nil || rows != 1 {
+			t.Errorf("verifying the email: got %d rows, error %v, want 1 row", rows, err)
+		}
+	})
+}
+
+func TestQuerierRefreshTokens(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db *Database) {
+		ctx := context.Background()
+		q := db.Querier()
+		user := createTestUser(t, q)
+		token := uuid.NewString()
+		_, err := q.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+			Token:     token,
+			UserID:    user.ID,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("failed to create refresh token: %v", err)
+		}
+		// Only a live token gets revoked.
+		for i, want := range []int64{1, 0} {
+			rows, err := q.RevokeRefreshToken(ctx, token)
+			if err != nil || rows != want {
+				t.Errorf("revocation %d: got %d rows, error %v, want %d rows", i+1, rows, err, want)
+			}
+		}
+		got, err := q.GetRefreshToken(ctx, token)
+		if err != nil {
+			t.Fatalf("failed to get refresh token: %v", err)
+		}
+		if got.UserID != user.ID || !got.RevokedAt.Valid {
+			t.Errorf("got refresh token %+v, want revoked token of %s", got, user.ID)
+		}
+	})
+}
+
+func TestQuerierVideosOutliveUploader(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db *Database) {
+		ctx := context.Background()
+		q := db.Querier()
+		owner := createTestUser(t, q)
+		uploader := createTestUser(t, q)
+		org := createTestOrganization(t, q, owner)
+		video, err := q.CreateVideo(ctx, database.CreateVideoParams{
+			ID:             uuid.NewString(),
+			Title:          "Title",
+			UserID:         &uploader.ID,
+			OrganizationID: org.ID,
+		})
+		if err != nil {
+			t.Fatalf("failed to create video: %v", err)
+		}
+		if err := q.DeleteUser(ctx, uploader.ID); err != nil {
+			t.Fatalf("failed to delete uploader: %v", err)
+		}
+		videos, err := q.GetVideosByOrganization(ctx, org.ID)
+		if err != nil {
+			t.Fatalf("failed to get organization videos: %v", err)
+		}
+		if len(videos) != 1 || videos[0].ID != video.ID || videos[0].UserID != nil {
+			t.Errorf("got videos %+v, want %s without uploader", videos, video.ID)
+		}
+		if err := q.DeleteOrganization(ctx, org.ID); err != nil {
+			t.Fatalf("failed to delete organization: %v", err)
+		}
+		if _, err := q.GetVideo(ctx, video.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("getting a video of a deleted organization: got error %v, want %v", err, sql.ErrNoRows)
+		}
+	})
+}
+
+func TestQuerierInvitations(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db *Database) {
+		ctx := context.Background()
+		q := db.Querier()
+		owner := createTestUser(t, q)
+		org := createTestOrganization(t, q, owner)
+		tokenHash := uuid.NewString()
+		err := q.CreateOrganizationInvitation(ctx, database.CreateOrganizationInvitationParams{
+			TokenHash:      tokenHash,
+			OrganizationID: org.ID,
+			Email:          "invitee@example.com",
+			Role:           OrgRoleViewer,
+			InvitedBy:      owner.ID,
+			ExpiresAt:      time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("failed to create invitation: %v", err)
+		}
+		// An invitation is used up by the first acceptance.
+		for i, want := range []int64{1, 0} {
+			rows, err := q.AcceptOrganizationInvitation(ctx, tokenHash)
+			if err != nil || rows != want {
+				t.Errorf("acceptance %d: got %d rows, error %v, want %d rows", i+1, rows, err, want)
+			}
+		}
+	})
+}
+
+func TestDatabaseInTxRollsBack(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db *Database) {
+		ctx := context.Background()
+		errAbort := errors.New("abort")
+		var user database.User
+		err := db.InTx(ctx, func(q database.Querier) error {
+			user = createTestUser(t, q)
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("got error %v, want %v", err, errAbort)
+		}
+		if _, err := db.Querier().GetUserByID(ctx, user.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("getting a user created in a rolled back transaction: got error %v, want %v", err, sql.ErrNoRows)
+		}
+	})
+}
+
+func TestQuerierVideoTitlesPerOrganization(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db *Database) {
+		ctx := context.Background()
+		q := db.Querier()
+		owner := createTestUser(t, q)
+		orgs := []database.Organization{createTestOrganization(t, q, owner), createTestOrganization(t, q, owner)}
+		// Organizations pick titles independently, but not twice within one.
+		for i, orgID := range []string{orgs[0].ID, orgs[1].ID, orgs[0].ID} {
+			_, err := q.CreateVideo(ctx, database.CreateVideoParams{
+				ID:             uuid.NewString(),
+				Title:          "Title",
+				UserID:         &owner.ID,
+				OrganizationID: orgID,
+			})
+			if want := i < 2; (err == nil) != want {
+				t.Errorf("video %d: got error %v, want success %t", i+1, err, want)
+			}
+		}
+	})
+}
+
+func TestDatabaseInTxConflict(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db *Database) {
+		ctx := context.Background()
+		user := createTestUser(t, db.Querier())
+		err := db.InTx(ctx, func(q database.Querier) error {
+			_, err := q.CreateUser(ctx, database.CreateUserParams{
+				ID:       uuid.NewString(),
+				Email:    user.Email,
+				Password: "hash-" + uuid.NewString(),
+			})
+			return err
+		})
+		if !errors.Is(err, ErrDuplicate) {
+			t.Errorf("creating a user with a taken email: got error %v, want %v", err, ErrDuplicate)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// ContentTypeProblemJSON is the media type of RFC 9457 problem details.
+const ContentTypeProblemJSON string = "application/problem+json"
+
+// Kinds of domain errors. Handlers usually wrap them in an *APIError with a
+// more specific code, WriteError maps them to a status either way.
+var (
+	ErrBadRequest           = errors.New("bad request")
+	ErrValidation           = errors.New("validation failed")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrNotFound             = errors.New("not found")
+	ErrConflict             = errors.New("conflict")
+	ErrPayloadTooLarge      = errors.New("payload too large")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrTooManyRequests      = errors.New("too many requests")
+)
+
+// CodeInternalError is the code of every error the server doesn't expect.
+const CodeInternalError string = "internal_error"
+
+type errorKind struct {
+	err    error
+	status int
+	code   string
+}
+
+// errorKinds gives each kind its status and the code used when no specific
+// one is set. Codes are part of the API, never change them.
+var errorKinds = []errorKind{
+	{ErrBadRequest, http.StatusBadRequest, "bad_request"},
+	{ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{ErrForbidden, http.StatusForbidden, "forbidden"},
+	{ErrNotFound, http.StatusNotFound, "not_found"},
+	{ErrConflict, http.StatusConflict, "conflict"},
+	{ErrPayloadTooLarge, http.StatusRequestEntityTooLarge, "payload_too_large"},
+	{ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+	{ErrTooManyRequests, http.StatusTooManyRequests, "too_many_requests"},
+}
+
+// APIError is a domain error as clients see it: a kind, a stable code and a
+// message. The cause in Err is only logged.
+type APIError struct {
+	Kind   error
+	Code   string
+	Detail string
+	Fields []FieldError
+	Err    error
+}
+
+// FieldError explains why a single field of the request was rejected.
+type FieldError struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// Codes of field errors.
+const (
+	FieldRequired string = "required"
+	FieldInvalid  string = "invalid"
+)
+
+func NewError(kind error, code, detail string) *APIError {
+	return &APIError{Kind: kind, Code: code, Detail: detail}
+}
+
+// ValidationError rejects a request because of the given fields.
+func ValidationError(fields ...FieldError) *APIError {
+	return &APIError{
+		Kind:   ErrValidation,
+		Code:   "validation_failed",
+		Detail: "the request has invalid fields",
+		Fields: fields,
+	}
+}
+
+func requiredField(field string) FieldError {
+	return FieldError{Field: field, Code: FieldRequired, Detail: field + " is required"}
+}
+
+func invalidField(field, detail string) FieldError {
+	return FieldError{Field: field, Code: FieldInvalid, Detail: detail}
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+func (e *APIError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Wrap returns a copy of e caused by err.
+func (e *APIError) Wrap(err error) *APIError {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// WithDetail returns a copy of e with another message.
+func (e *APIError) WithDetail(detail string) *APIError {
+	changed := *e
+	changed.Detail = detail
+	return &changed
+}
+
+// problem is an RFC 9457 problem details body. code and request_id are
+// extension members.
+type problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+func newProblem(status int, code, detail string) problem {
+	return problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// problemFor maps err to the problem clients get, a 500 unless err is a
+// domain error.
+func problemFor(err error) problem {
+	var apiErr *APIError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &apiErr):
+		status, code := http.StatusInternalServerError, CodeInternalError
+		for _, kind := range errorKinds {
+			if errors.Is(apiErr.Kind, kind.err) {
+				status, code = kind.status, kind.code
+				break
+			}
+		}
+		if apiErr.Code != "" {
+			code = apiErr.Code
+		}
+		p := newProblem(status, code, apiErr.Detail)
+		p.Errors = apiErr.Fields
+		return p
+	case errors.As(err, &maxBytesErr):
+		return newProblem(http.StatusRequestEntityTooLarge, "payload_too_large", fmt.Sprintf("the request body is larger than %d bytes", maxBytesErr.Limit))
+	case errors.Is(err, sql.ErrNoRows):
+		return newProblem(http.StatusNotFound, "not_found", "resource not found")
+	}
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.err) {
+			return newProblem(kind.status, kind.code, err.Error())
+		}
+	}
+	return newProblem(http.StatusInternalServerError, CodeInternalError, "internal server error")
+}
+
+// codeForStatus is the code of errors written with a bare status.
+func codeForStatus(status int) string {
+	if status >= http.StatusInternalServerError {
+		return CodeInternalError
+	}
+	for _, kind := range errorKinds {
+		if kind.status == status {
+			return kind.code
+		}
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// WriteError answers with the problem details of err. Unexpected errors are
+// logged, clients only get a generic message for them.
+func WriteError(res http.ResponseWriter, req *http.Request, err error) {
+	p := problemFor(err)
+	if p.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(req.Context(), "request failed", "error", err)
+	}
+	p.Instance = req.URL.Path
+	writeProblem(res, p)
+}
+
+func writeProblem(res http.ResponseWriter, p problem) {
+	p.RequestID = res.Header().Get(RequestIDHeader)
+	data, err := json.Marshal(p)
+	if err != nil {
+		http.Error(res, ErrMarshalPayload, http.StatusInternalServerError)
+		return
+	}
+	// Headers set after WriteHeader are dropped.
+	res.Header().Set("Content-Type", ContentTypeProblemJSON)
+	res.WriteHeader(p.Status)
+	res.Write(data)
+}
@@ -2,51 +2,120 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"path/filepath"
+
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/google/uuid"
 )
 
-type errorResponse struct {
-	Error string `json:"error"`
+// Error writes a problem details response with the code that goes with
+// status. It carries the request id so that clients can point us at the
+// matching server logs.
+func Error(res http.ResponseWriter, msg string, code int) {
+	writeProblem(res, newProblem(code, codeForStatus(code), msg))
 }
 
-func Error(res http.ResponseWriter, msg string, code int) {
-	errorPayload := errorResponse{
-		Error: msg,
-	}
-	data, err := json.Marshal(errorPayload)
-	if err != nil {
-		http.Error(res, ErrMarshalPayload, http.StatusInternalServerError)
+// ServerError logs the underlying cause of an internal error, which clients
+// never get to see, and answers with a 500. Domain errors keep their own
+// status.
+func ServerError(res http.ResponseWriter, req *http.Request, msg string, err error) {
+	if p := problemFor(err); p.Status < http.StatusInternalServerError {
+		WriteError(res, req, err)
 		return
 	}
-	res.WriteHeader(code)
-	res.Header().Set("Content-Type", "application/json")
-	res.Write(data)
+	slog.ErrorContext(req.Context(), msg, "error", err)
+	p := newProblem(http.StatusInternalServerError, CodeInternalError, msg)
+	p.Instance = req.URL.Path
+	writeProblem(res, p)
 }
 
 func AppHandler(cfg *Config) http.Handler {
 	return http.FileServer(http.Dir(cfg.AppDirPath))
 }
 
+// AppPageHandler serves the app on paths it routes itself, such as the links
+// sent by email.
+func AppPageHandler(cfg *Config) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		http.ServeFile(res, req, filepath.Join(cfg.AppDirPath, "index.html"))
+	}
+}
+
 func AssetsHandler(cfg *Config) http.Handler {
 	return http.FileServer(http.Dir(cfg.AssetsDirPath))
 }
 
-func ResetHandler(cfg *Config) http.HandlerFunc {
+func JWKSHandler(cfg *Config) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
-		if cfg.Platform != AllowedPlatform {
-			Error(res, "reset is only allowed in dev environment.", http.StatusForbidden)
-			return
-		}
-		if err := cfg.DB.DeleteAllUsers(req.Context()); err != nil {
-			Error(res, "failed to reset 'users' table", http.StatusInternalServerError)
+		data, err := json.Marshal(cfg.TokenKeys.JWKS())
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
 			return
 		}
-		if err := cfg.DB.DeleteAllRefreshTokens(req.Context()); err != nil {
-			Error(res, "failed to reset 'referesh_tokens' table", http.StatusInternalServerError)
+		res.Header().Set("Content-Type", "application/json")
+		res.Header().Set("Cache-Control", "max-age=300")
+		res.Write(data)
+	}
+}
+
+func ResetHandler(cfg *Config, adminUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if cfg.Platform != AllowedPlatform {
+			Error(res, "reset is only allowed in dev environment.", http.StatusForbidden)
 			return
 		}
-		if err := cfg.DB.DeleteAllVideos(req.Context()); err != nil {
-			Error(res, "failed to reset 'videos' table", http.StatusInternalServerError)
+		// The tables go together with the audit entry of the reset, or not at all.
+		err := cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.DeleteAllUsers(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'users' table: %w", err)
+			}
+			if err := q.DeleteAllRefreshTokens(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'referesh_tokens' table: %w", err)
+			}
+			if err := q.DeleteAllVideos(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'videos' table: %w", err)
+			}
+			if err := q.DeleteAllPasswordResetTokens(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'password_reset_tokens' table: %w", err)
+			}
+			if err := q.DeleteAllEmailVerificationTokens(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'email_verification_tokens' table: %w", err)
+			}
+			if err := q.DeleteAllRecoveryCodes(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'mfa_recovery_codes' table: %w", err)
+			}
+			if err := q.DeleteAllMFAChallenges(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'mfa_challenges' table: %w", err)
+			}
+			if err := q.DeleteAllUserIdentities(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'user_identities' table: %w", err)
+			}
+			if err := q.DeleteAllOIDCLoginStates(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'oidc_login_states' table: %w", err)
+			}
+			if err := q.DeleteAllOrganizationInvitations(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'organization_invitations' table: %w", err)
+			}
+			if err := q.DeleteAllOrganizationMembers(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'organization_members' table: %w", err)
+			}
+			if err := q.DeleteAllOrganizations(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'organizations' table: %w", err)
+			}
+			if err := q.DeleteAllWebhookSubscriptions(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'webhook_subscriptions' table: %w", err)
+			}
+			if err := q.DeleteAllOutboxEvents(req.Context()); err != nil {
+				return fmt.Errorf("failed to reset 'outbox_events' table: %w", err)
+			}
+			// audit_events is append-only and survives resets, which get recorded there.
+			return recordAudit(req, cfg, q, auditEvent{Action: AuditAdminReset, ActorID: adminUUID.String()})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to reset database", err)
 			return
 		}
 		res.WriteHeader(http.StatusOK)
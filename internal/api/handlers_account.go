@@ -0,0 +1,342 @@
+package api
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrIncorrectPassword    = NewError(ErrUnauthorized, "incorrect_password", "Incorrect password")
+	ErrDeletionNotScheduled = NewError(ErrConflict, "deletion_not_scheduled", "account is not scheduled for deletion")
+)
+
+type updateUserPayload struct {
+	Email *string `json:"email"`
+}
+
+type changePasswordPayload struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func GetCurrentUserHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		data, err := json.Marshal(newUserResponse(user))
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+func UpdateCurrentUserHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := updateUserPayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		if params.Email != nil {
+			email, err := auth.NormalizeEmail(*params.Email)
+			if err != nil {
+				WriteError(res, req, ValidationError(invalidField("email", err.Error())))
+				return
+			}
+			if email != user.Email {
+				if _, err := cfg.DB.GetUserByEmail(req.Context(), email); err == nil {
+					WriteError(res, req, ErrEmailAlreadyInUse)
+					return
+				}
+				// A new address has to be verified again before it's trusted.
+				emailParams := database.UpdateUserEmailParams{
+					Email: email,
+					ID:    user.ID,
+				}
+				err = cfg.InTx(req.Context(), func(q database.Querier) error {
+					var err error
+					previousEmail := user.Email
+					if user, err = q.UpdateUserEmail(req.Context(), emailParams); err != nil {
+						return err
+					}
+					if err := recordEvent(req.Context(), q, userEvent(EventUserEmailChanged, user)); err != nil {
+						return err
+					}
+					return recordAudit(req, cfg, q, auditEvent{
+						Action:     AuditEmailChanged,
+						ActorID:    user.ID,
+						TargetType: AuditTargetUser,
+						TargetID:   user.ID,
+						Diff:       map[string]auditChange{"email": {From: previousEmail, To: user.Email}},
+					})
+				})
+				if err != nil {
+					ServerError(res, req, "failed to update email", err)
+					return
+				}
+				if err := sendVerificationEmail(req.Context(), cfg, user.ID, user.Email); err != nil {
+					slog.WarnContext(req.Context(), "failed to send verification email", "error", err)
+				}
+			}
+		}
+		data, err := json.Marshal(newUserResponse(user))
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+// ChangePasswordHandler revokes every refresh token of the user and answers
+// with a new session, so only the caller stays logged in.
+func ChangePasswordHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := changePasswordPayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		if params.NewPassword == "" {
+			WriteError(res, req, ValidationError(requiredField("new_password")))
+			return
+		}
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		if err := auth.CheckPasswordHash(user.Password, params.CurrentPassword); err != nil {
+			WriteError(res, req, ErrIncorrectPassword)
+			return
+		}
+		hashedPassword, err := auth.HashPassword(params.NewPassword)
+		if err != nil {
+			ServerError(res, req, err.Error(), err)
+			return
+		}
+		passwordParams := database.UpdateUserPasswordParams{
+			Password: hashedPassword,
+			ID:       user.ID,
+		}
+		var session userPayload
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.UpdateUserPassword(req.Context(), passwordParams); err != nil {
+				return err
+			}
+			if err := q.RevokeAllRefreshTokensByUser(req.Context(), user.ID); err != nil {
+				return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+			}
+			if err := recordUserEvent(req.Context(), q, EventUserPasswordChanged, user.ID); err != nil {
+				return err
+			}
+			var err error
+			if session, err = newSession(req.Context(), cfg, q, user); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditPasswordChanged,
+				ActorID:    user.ID,
+				TargetType: AuditTargetUser,
+				TargetID:   user.ID,
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to update password", err)
+			return
+		}
+		writeSession(res, req, session)
+	}
+}
+
+type deletionPayload struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+// ExportUserDataHandler streams a ZIP archive with the user's profile, video
+// metadata and thumbnails. Original media is only included with ?include_media=true.
+func ExportUserDataHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		includeMedia := false
+		if value := req.URL.Query().Get("include_media"); value != "" {
+			var err error
+			includeMedia, err = strconv.ParseBool(value)
+			if err != nil {
+				WriteError(res, req, ValidationError(invalidField("include_media", "invalid include_media parameter")))
+				return
+			}
+		}
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		videos, err := cfg.DB.GetVideosByUser(req.Context(), &user.ID)
+		if err != nil {
+			ServerError(res, req, "failed to get videos", err)
+			return
+		}
+		if videos == nil {
+			videos = []database.Video{}
+		}
+		res.Header().Set("Content-Type", "application/zip")
+		res.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"export-%s.zip\"", time.Now().Format("20060102")))
+		// Headers are sent with the first byte, so from here on failures can only be logged.
+		archive := zip.NewWriter(res)
+		defer archive.Close()
+		if err := writeZipJSON(archive, "profile.json", newUserResponse(user)); err != nil {
+			slog.ErrorContext(req.Context(), "failed to export profile", "error", err)
+			return
+		}
+		if err := writeZipJSON(archive, "videos.json", videos); err != nil {
+			slog.ErrorContext(req.Context(), "failed to export videos", "error", err)
+			return
+		}
+		for _, video := range videos {
+			if filePath, ok := thumbnailPath(cfg, video.ThumbnailUrl); ok {
+				file, err := os.Open(filePath)
+				if err != nil {
+					slog.WarnContext(req.Context(), "failed to open thumbnail", "path", filePath, "error", err)
+					continue
+				}
+				err = writeZipFile(archive, path.Join("thumbnails", video.ID+path.Ext(filePath)), file)
+				file.Close()
+				if err != nil {
+					slog.ErrorContext(req.Context(), "failed to export thumbnail", "error", err)
+					return
+				}
+			}
+			if !includeMedia {
+				continue
+			}
+			if key, ok := videoObjectKey(cfg, video.VideoUrl); ok {
+				body, err := openVideoObject(req.Context(), cfg, key)
+				if err != nil {
+					slog.WarnContext(req.Context(), "failed to open video", "key", key, "error", err)
+					continue
+				}
+				err = writeZipFile(archive, path.Join("videos", video.ID+path.Ext(key)), body)
+				body.Close()
+				if err != nil {
+					slog.ErrorContext(req.Context(), "failed to export video", "error", err)
+					return
+				}
+			}
+		}
+	}
+}
+
+func writeZipJSON(archive *zip.Writer, name string, payload any) error {
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return err
+	}
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func writeZipFile(archive *zip.Writer, name string, r io.Reader) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// DeleteCurrentUserHandler schedules the account for deletion once the grace
+// period is over and logs the user out everywhere. Until then it can be restored.
+func DeleteCurrentUserHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		deletionTime := time.Now().Add(cfg.AccountDeletionGracePeriod)
+		if user.DeletionScheduledAt.Valid {
+			deletionTime = user.DeletionScheduledAt.Time
+		}
+		deletionParams := database.ScheduleUserDeletionParams{
+			DeletionScheduledAt: sql.NullTime{Time: deletionTime, Valid: true},
+			ID:                  user.ID,
+		}
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.ScheduleUserDeletion(req.Context(), deletionParams); err != nil {
+				return err
+			}
+			if err := q.RevokeAllRefreshTokensByUser(req.Context(), user.ID); err != nil {
+				return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+			}
+			return recordUserEvent(req.Context(), q, EventUserDeletionScheduled, user.ID)
+		})
+		if err != nil {
+			ServerError(res, req, "failed to schedule account deletion", err)
+			return
+		}
+		data, err := json.Marshal(deletionPayload{DeletionScheduledAt: deletionTime})
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusAccepted)
+		res.Write(data)
+	}
+}
+
+func RestoreCurrentUserHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		if !user.DeletionScheduledAt.Valid {
+			WriteError(res, req, ErrDeletionNotScheduled)
+			return
+		}
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.CancelUserDeletion(req.Context(), user.ID); err != nil {
+				return err
+			}
+			return recordUserEvent(req.Context(), q, EventUserDeletionCancelled, user.ID)
+		})
+		if err != nil {
+			ServerError(res, req, "failed to cancel account deletion", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageSize          int64         = 50
+	MaxPageSize              int64         = 200
+	MaxImpersonationDuration time.Duration = time.Hour
+)
+
+var (
+	ErrTargetIsSelf       = NewError(ErrBadRequest, "target_is_self", "admins can't perform this action on themselves")
+	ErrInvalidUserID      = NewError(ErrBadRequest, "invalid_user_id", "failed to parse user ID")
+	ErrAdminImpersonation = NewError(ErrForbidden, "admin_impersonation", "admins can't be impersonated")
+)
+
+type impersonationPayload struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// parsePagination reads the limit and offset query parameters.
+func parsePagination(req *http.Request) (int64, int64, error) {
+	limit, offset := DefaultPageSize, int64(0)
+	query := req.URL.Query()
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed <= 0 {
+			return 0, 0, ValidationError(invalidField("limit", "invalid limit parameter"))
+		}
+		limit = min(parsed, MaxPageSize)
+	}
+	if value := query.Get("offset"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			return 0, 0, ValidationError(invalidField("offset", "invalid offset parameter"))
+		}
+		offset = parsed
+	}
+	return limit, offset, nil
+}
+
+func AdminListUsersHandler(cfg *Config, adminUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		limit, offset, err := parsePagination(req)
+		if err != nil {
+			WriteError(res, req, err)
+			return
+		}
+		users, err := cfg.DB.ListUsers(req.Context(), database.ListUsersParams{Limit: limit, Offset: offset})
+		if err != nil {
+			ServerError(res, req, "failed to list users", err)
+			return
+		}
+		usersPayload := make([]userResponse, 0, len(users))
+		for _, user := range users {
+			usersPayload = append(usersPayload, newUserResponse(user))
+		}
+		data, err := json.Marshal(usersPayload)
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+// adminTargetUser loads the user named in the path, refusing to act on the admin itself.
+func adminTargetUser(res http.ResponseWriter, req *http.Request, cfg *Config, adminUUID uuid.UUID) (database.User, bool) {
+	targetUUID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		WriteError(res, req, ErrInvalidUserID)
+		return database.User{}, false
+	}
+	if targetUUID == adminUUID {
+		WriteError(res, req, ErrTargetIsSelf)
+		return database.User{}, false
+	}
+	user, err := cfg.DB.GetUserByID(req.Context(), targetUUID.String())
+	if err != nil {
+		WriteError(res, req, ErrUserNotFound)
+		return database.User{}, false
+	}
+	return user, true
+}
+
+func AdminDisableUserHandler(cfg *Config, adminUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		user, ok := adminTargetUser(res, req, cfg, adminUUID)
+		if !ok {
+			return
+		}
+		err := cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.DisableUser(req.Context(), user.ID); err != nil {
+				return err
+			}
+			if err := q.RevokeAllRefreshTokensByUser(req.Context(), user.ID); err != nil {
+				return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+			}
+			if err := recordUserEvent(req.Context(), q, EventUserDisabled, user.ID); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditAdminUserDisabled,
+				ActorID:    adminUUID.String(),
+				TargetType: AuditTargetUser,
+				TargetID:   user.ID,
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to disable user", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func AdminEnableUserHandler(cfg *Config, adminUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		user, ok := adminTargetUser(res, req, cfg, adminUUID)
+		if !ok {
+			return
+		}
+		err := cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.EnableUser(req.Context(), user.ID); err != nil {
+				return err
+			}
+			if err := recordUserEvent(req.Context(), q, EventUserEnabled, user.ID); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditAdminUserEnabled,
+				ActorID:    adminUUID.String(),
+				TargetType: AuditTargetUser,
+				TargetID:   user.ID,
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to enable user", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AdminDeleteUserHandler deletes the account right away, skipping the grace period.
+func AdminDeleteUserHandler(cfg *Config, adminUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		user, ok := adminTargetUser(res, req, cfg, adminUUID)
+		if !ok {
+			return
+		}
+		err := purgeAccount(req.Context(), cfg, user.ID, sql.NullTime{}, func(q database.Querier) error {
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditAdminUserDeleted,
+				ActorID:    adminUUID.String(),
+				TargetType: AuditTargetUser,
+				TargetID:   user.ID,
+				Diff:       map[string]auditChange{"email": {From: user.Email}},
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to delete user", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func AdminImpersonateUserHandler(cfg *Config, adminUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		user, ok := adminTargetUser(res, req, cfg, adminUUID)
+		if !ok {
+			return
+		}
+		if user.Role == RoleAdmin {
+			WriteError(res, req, ErrAdminImpersonation)
+			return
+		}
+		if user.DisabledAt.Valid {
+			WriteError(res, req, ErrAccountDisabled)
+			return
+		}
+		userUUID, err := uuid.Parse(user.ID)
+		if err != nil {
+			ServerError(res, req, "failed to parse uuid", err)
+			return
+		}
+		token, err := auth.MakeImpersonationJWT(userUUID, adminUUID, cfg.TokenKeys, MaxImpersonationDuration)
+		if err != nil {
+			ServerError(res, req, ErrMakeJWT, err)
+			return
+		}
+		// No token leaves without its audit entry.
+		err = recordAudit(req, cfg, cfg.DB, auditEvent{
+			Action:     AuditAdminImpersonated,
+			ActorID:    adminUUID.String(),
+			TargetType: AuditTargetUser,
+			TargetID:   user.ID,
+		})
+		if err != nil {
+			ServerError(res, req, "failed to impersonate user", err)
+			return
+		}
+		payload := impersonationPayload{
+			Token:     token,
+			ExpiresAt: time.Now().Add(MaxImpersonationDuration),
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+func AdminListVideosHandler(cfg *Config, adminUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		limit, offset, err := parsePagination(req)
+		if err != nil {
+			WriteError(res, req, err)
+			return
+		}
+		videos, err := cfg.DB.ListVideos(req.Context(), database.ListVideosParams{Limit: limit, Offset: offset})
+		if err != nil {
+			ServerError(res, req, "failed to list videos", err)
+			return
+		}
+		if videos == nil {
+			videos = []database.Video{}
+		}
+		data, err := json.Marshal(videos)
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+// AdminTakedownVideoHandler removes a video and its stored media regardless of owner.
+func AdminTakedownVideoHandler(cfg *Config, adminUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		videoUUID, err := uuid.Parse(req.PathValue("videoID"))
+		if err != nil {
+			WriteError(res, req, ErrInvalidVideoID)
+			return
+		}
+		video, err := cfg.DB.GetVideo(req.Context(), videoUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrVideoNotFound)
+			return
+		}
+		if err := deleteVideoBlobs(req.Context(), cfg, video); err != nil {
+			ServerError(res, req, "failed to delete video media", err)
+			return
+		}
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.DeleteVideo(req.Context(), video.ID); err != nil {
+				return err
+			}
+			if err := recordEvent(req.Context(), q, videoEvent(EventVideoDeleted, video)); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditAdminVideoTakedown,
+				ActorID:    adminUUID.String(),
+				TargetType: AuditTargetVideo,
+				TargetID:   video.ID,
+				Diff:       map[string]auditChange{"title": {From: video.Title}},
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to delete video", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// BootstrapAdmin promotes the account with email to admin, creating it with
+// password first if it doesn't exist yet.
+func BootstrapAdmin(ctx context.Context, cfg *Config, email, password string) error {
+	email, err := auth.NormalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	return cfg.InTx(ctx, func(q database.Querier) error {
+		user, err := q.GetUserByEmail(ctx, email)
+		if errors.Is(err, sql.ErrNoRows) {
+			if password == "" {
+				return errors.New("a password is required to create a new admin")
+			}
+			hashedPassword, err := auth.HashPassword(password)
+			if err != nil {
+				return err
+			}
+			userParams := database.CreateVerifiedUserParams{
+				ID:       uuid.New().String(),
+				Email:    email,
+				Password: hashedPassword,
+			}
+			user, err = q.CreateVerifiedUser(ctx, userParams)
+			if err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+			if err := recordEvent(ctx, q, userEvent(EventUserCreated, user)); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		roleParams := database.SetUserRoleParams{
+			Role: RoleAdmin,
+			ID:   user.ID,
+		}
+		if err := q.SetUserRole(ctx, roleParams); err != nil {
+			return fmt.Errorf("failed to promote user: %w", err)
+		}
+		return recordUserEvent(ctx, q, EventUserRoleChanged, user.ID)
+	})
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/google/uuid"
+)
+
+const AuditExportContentType string = "application/x-ndjson"
+
+type auditEventResponse struct {
+	ID             int64           `json:"id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Action         string          `json:"action"`
+	ActorID        *string         `json:"actor_id"`
+	ImpersonatorID *string         `json:"impersonator_id"`
+	IP             string          `json:"ip"`
+	TargetType     string          `json:"target_type"`
+	TargetID       string          `json:"target_id"`
+	Diff           json.RawMessage `json:"diff"`
+}
+
+func newAuditEventResponse(event database.AuditEvent) auditEventResponse {
+	response := auditEventResponse{
+		ID:         event.ID,
+		CreatedAt:  event.CreatedAt,
+		Action:     event.Action,
+		IP:         event.Ip,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Diff:       json.RawMessage(event.Diff),
+	}
+	if event.ActorID.Valid {
+		response.ActorID = &event.ActorID.String
+	}
+	if event.ImpersonatorID.Valid {
+		response.ImpersonatorID = &event.ImpersonatorID.String
+	}
+	return response
+}
+
+// parseAuditFilters reads the actor_id, action, target_type, target_id, since,
+// until (RFC 3339) and before_id query parameters.
+func parseAuditFilters(req *http.Request) (database.ListAuditEventsParams, error) {
+	query := req.URL.Query()
+	optional := func(name string) sql.NullString {
+		value := query.Get(name)
+		return sql.NullString{String: value, Valid: value != ""}
+	}
+	params := database.ListAuditEventsParams{
+		ActorID:    optional("actor_id"),
+		Action:     optional("action"),
+		TargetType: optional("target_type"),
+		TargetID:   optional("target_id"),
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return params, ValidationError(invalidField("since", "invalid since parameter"))
+		}
+		params.Since = sql.NullTime{Time: since.UTC(), Valid: true}
+	}
+	if value := query.Get("until"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return params, ValidationError(invalidField("until", "invalid until parameter"))
+		}
+		params.Until = sql.NullTime{Time: until.UTC(), Valid: true}
+	}
+	if value := query.Get("before_id"); value != "" {
+		beforeID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return params, ValidationError(invalidField("before_id", "invalid before_id parameter"))
+		}
+		params.BeforeID = sql.NullInt64{Int64: beforeID, Valid: true}
+	}
+	return params, nil
+}
+
+// AdminListAuditEventsHandler returns audit events, newest first.
+func AdminListAuditEventsHandler(cfg *Config, adminUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params, err := parseAuditFilters(req)
+		if err != nil {
+			WriteError(res, req, err)
+			return
+		}
+		params.Limit, params.Offset, err = parsePagination(req)
+		if err != nil {
+			WriteError(res, req, err)
+			return
+		}
+		events, err := cfg.DB.ListAuditEvents(req.Context(), params)
+		if err != nil {
+			ServerError(res, req, "failed to list audit events", err)
+			return
+		}
+		eventsPayload := make([]auditEventResponse, 0, len(events))
+		for _, event := range events {
+			eventsPayload = append(eventsPayload, newAuditEventResponse(event))
+		}
+		data, err := json.Marshal(eventsPayload)
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+// AdminExportAuditEventsHandler streams every audit event matching the filters
+// as JSON lines. Pages are walked by id so events appended meanwhile don't
+// shift the export.
+func AdminExportAuditEventsHandler(cfg *Config, adminUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params, err := parseAuditFilters(req)
+		if err != nil {
+			WriteError(res, req, err)
+			return
+		}
+		params.Limit = MaxPageSize
+		events, err := cfg.DB.ListAuditEvents(req.Context(), params)
+		if err != nil {
+			ServerError(res, req, "failed to list audit events", err)
+			return
+		}
+		res.Header().Set("Content-Type", AuditExportContentType)
+		res.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+		encoder := json.NewEncoder(res)
+		for {
+			for _, event := range events {
+				if err := encoder.Encode(newAuditEventResponse(event)); err != nil {
+					return
+				}
+			}
+			if int64(len(events)) < params.Limit {
+				return
+			}
+			params.BeforeID = sql.NullInt64{Int64: events[len(events)-1].ID, Valid: true}
+			events, err = cfg.DB.ListAuditEvents(req.Context(), params)
+			if err != nil {
+				// The status line is gone already, all we can do is cut the stream short.
+				return
+			}
+		}
+	}
+}
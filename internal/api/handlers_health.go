@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HealthStatusOK          string        = "ok"
+	HealthStatusUnavailable string        = "unavailable"
+	ReadinessCheckTimeout   time.Duration = 3 * time.Second
+)
+
+type checkResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Version   string  `json:"version,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type healthPayload struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+// readinessCheck probes one dependency and returns its version, if it has one.
+type readinessCheck func(ctx context.Context, cfg *Config) (string, error)
+
+var readinessChecks = map[string]readinessCheck{
+	"database":     checkDatabase,
+	"assets_dir":   checkAssetsDir,
+	"ffmpeg":       checkCommandVersion("ffmpeg"),
+	"ffprobe":      checkCommandVersion("ffprobe"),
+	"blob_storage": checkBlobStorage,
+}
+
+func checkDatabase(ctx context.Context, cfg *Config) (string, error) {
+	return "", cfg.DBConn.PingContext(ctx)
+}
+
+func checkAssetsDir(ctx context.Context, cfg *Config) (string, error) {
+	file, err := os.CreateTemp(cfg.AssetsDirPath, ".readyz-*")
+	if err != nil {
+		return "", err
+	}
+	file.Close()
+	return "", os.Remove(file.Name())
+}
+
+// checkCommandVersion runs "<name> -version" and reports the version from the
+// first line of its output, e.g. "ffmpeg version 6.1.1 Copyright ...".
+func checkCommandVersion(name string) readinessCheck {
+	return func(ctx context.Context, cfg *Config) (string, error) {
+		output, err := exec.CommandContext(ctx, name, "-version").Output()
+		if err != nil {
+			return "", err
+		}
+		line, _, _ := strings.Cut(string(output), "\n")
+		words := strings.Fields(line)
+		if len(words) < 3 || words[1] != "version" {
+			return "", errors.New("unexpected version output: " + line)
+		}
+		return words[2], nil
+	}
+}
+
+func checkBlobStorage(ctx context.Context, cfg *Config) (string, error) {
+	return "", cfg.Storage.Check(ctx)
+}
+
+func writeHealth(res http.ResponseWriter, payload healthPayload) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		Error(res, ErrMarshalPayload, http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	if payload.Status != HealthStatusOK {
+		res.WriteHeader(http.StatusServiceUnavailable)
+	}
+	res.Write(data)
+}
+
+// HealthzHandler answers as long as the process is able to serve requests.
+func HealthzHandler(cfg *Config) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		writeHealth(res, healthPayload{Status: HealthStatusOK})
+	}
+}
+
+// ReadyzHandler runs every dependency check concurrently. The server stops
+// being ready as soon as it starts shutting down, so load balancers move
+// traffic away before connections are closed.
+func ReadyzHandler(cfg *Config) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), ReadinessCheckTimeout)
+		defer cancel()
+		payload := healthPayload{
+			Status: HealthStatusOK,
+			Checks: map[string]checkResult{},
+		}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for name, check := range readinessChecks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				start := time.Now()
+				version, err := check(ctx, cfg)
+				result := checkResult{
+					Status:    HealthStatusOK,
+					LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+					Version:   version,
+				}
+				if err != nil {
+					result.Status = HealthStatusUnavailable
+					result.Error = err.Error()
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				payload.Checks[name] = result
+				if err != nil {
+					payload.Status = HealthStatusUnavailable
+				}
+			}()
+		}
+		wg.Wait()
+		if cfg.ShuttingDown.Load() {
+			payload.Status = HealthStatusUnavailable
+			payload.Checks["shutdown"] = checkResult{Status: HealthStatusUnavailable, Error: "server is shutting down"}
+		}
+		writeHealth(res, payload)
+	}
+}
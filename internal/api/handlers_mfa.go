@@ -0,0 +1,371 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	MaxMFAChallengeLength time.Duration = time.Minute * 5
+	TOTPIssuer            string        = "Video Manager"
+)
+
+var (
+	ErrInvalidMFACode        = NewError(ErrUnauthorized, "invalid_mfa_code", "invalid authentication code")
+	ErrInvalidMFAToken       = NewError(ErrUnauthorized, "invalid_mfa_token", "invalid or expired mfa token")
+	ErrMFAAlreadyEnabled     = NewError(ErrConflict, "mfa_already_enabled", "two-factor authentication is already enabled")
+	ErrMFANotEnabled         = NewError(ErrConflict, "mfa_not_enabled", "two-factor authentication is not enabled")
+	ErrNoPendingMFAEnrolment = NewError(ErrConflict, "mfa_enrolment_not_pending", "no pending two-factor enrolment")
+)
+
+type mfaChallengePayload struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+type totpEnrollPayload struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type recoveryCodesPayload struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type mfaCodePayload struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+func writeMFAChallenge(res http.ResponseWriter, req *http.Request, cfg *Config, user database.User) {
+	mfaToken, err := newMFAChallenge(req.Context(), cfg, user)
+	if err != nil {
+		ServerError(res, req, "failed to create mfa challenge", err)
+		return
+	}
+	data, err := json.Marshal(mfaChallengePayload{MFARequired: true, MFAToken: mfaToken})
+	if err != nil {
+		ServerError(res, req, ErrMarshalPayload, err)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(data)
+}
+
+// newMFAChallenge stores a single use challenge for user and returns its token.
+func newMFAChallenge(ctx context.Context, cfg *Config, user database.User) (string, error) {
+	if user.DisabledAt.Valid {
+		return "", ErrAccountDisabled
+	}
+	if err := cfg.DB.DeleteExpiredMFAChallenges(ctx, time.Now()); err != nil {
+		return "", fmt.Errorf("failed to clean up mfa challenges: %w", err)
+	}
+	mfaToken, err := auth.MakeSecureToken()
+	if err != nil {
+		return "", err
+	}
+	challengeParams := database.CreateMFAChallengeParams{
+		ChallengeHash: auth.HashToken(mfaToken),
+		UserID:        user.ID,
+		ExpiresAt:     time.Now().Add(MaxMFAChallengeLength),
+	}
+	if err := cfg.DB.CreateMFAChallenge(ctx, challengeParams); err != nil {
+		return "", err
+	}
+	return mfaToken, nil
+}
+
+// checkSecondFactor accepts either a current TOTP code or an unused recovery code,
+// which is consumed. A TOTP code is only accepted once.
+func checkSecondFactor(ctx context.Context, cfg *Config, user database.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		secret, err := auth.OpenTOTPSecret(cfg.TOTPKey, user.ID, user.TotpSecret.String)
+		if err != nil {
+			return false, err
+		}
+		step, ok := auth.ValidateTOTP(secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		rows, err := cfg.DB.UseTOTPStep(ctx, database.UseTOTPStepParams{Step: step, ID: user.ID})
+		if err != nil {
+			return false, err
+		}
+		return rows == 1, nil
+	}
+	if recoveryCode == "" {
+		return false, nil
+	}
+	codeParams := database.UseRecoveryCodeParams{
+		CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(recoveryCode)),
+		UserID:   user.ID,
+	}
+	rows, err := cfg.DB.UseRecoveryCode(ctx, codeParams)
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func LoginMFAHandler(cfg *Config) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := mfaCodePayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		// Challenges are single use, a wrong code means logging in again.
+		consumeParams := database.ConsumeMFAChallengeParams{
+			ChallengeHash: auth.HashToken(params.MFAToken),
+			ExpiresAt:     time.Now(),
+		}
+		challenge, err := cfg.DB.ConsumeMFAChallenge(req.Context(), consumeParams)
+		if err != nil {
+			WriteError(res, req, ErrInvalidMFAToken)
+			return
+		}
+		throttleKey := "mfa:" + challenge.UserID
+		if wait := cfg.LoginThrottle.Check(throttleKey); wait > 0 {
+			TooManyRequests(res, wait)
+			return
+		}
+		user, err := cfg.DB.GetUserByID(req.Context(), challenge.UserID)
+		if err != nil || !user.TotpEnabledAt.Valid {
+			WriteError(res, req, ErrInvalidMFAToken)
+			return
+		}
+		ok, err := checkSecondFactor(req.Context(), cfg, user, params.Code, params.RecoveryCode)
+		if err != nil {
+			ServerError(res, req, "failed to check authentication code", err)
+			return
+		}
+		if !ok {
+			cfg.LoginThrottle.Failure(throttleKey)
+			recordAttempt(req, cfg, auditEvent{
+				Action:     AuditLoginFailed,
+				TargetType: AuditTargetUser,
+				TargetID:   user.ID,
+				Diff:       map[string]string{"method": "mfa"},
+			})
+			WriteError(res, req, ErrInvalidMFACode)
+			return
+		}
+		cfg.LoginThrottle.Success(throttleKey)
+		cfg.AccountThrottle.Success(accountThrottleKey(user.Email))
+		writeLogin(res, req, cfg, user, "mfa")
+	}
+}
+
+// sealTOTPSecrets encrypts the TOTP secrets stored in plain text before
+// secrets were encrypted. Replacing is conditional on the secret being
+// unchanged, so replicas starting together don't seal a secret twice.
+func sealTOTPSecrets(ctx context.Context, db database.Querier, key []byte) error {
+	users, err := db.GetUsersWithTOTPSecret(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get totp secrets: %w", err)
+	}
+	for _, user := range users {
+		if auth.IsSealedTOTPSecret(user.TotpSecret.String) {
+			continue
+		}
+		sealed, err := auth.SealTOTPSecret(key, user.ID, user.TotpSecret.String)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt totp secret: %w", err)
+		}
+		replaceParams := database.ReplaceUserTOTPSecretParams{
+			NewSecret: sql.NullString{String: sealed, Valid: true},
+			ID:        user.ID,
+			OldSecret: user.TotpSecret,
+		}
+		if _, err := db.ReplaceUserTOTPSecret(ctx, replaceParams); err != nil {
+			return fmt.Errorf("failed to encrypt totp secret: %w", err)
+		}
+	}
+	return nil
+}
+
+func EnrollTOTPHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		if user.TotpEnabledAt.Valid {
+			WriteError(res, req, ErrMFAAlreadyEnabled)
+			return
+		}
+		secret, err := auth.GenerateTOTPSecret()
+		if err != nil {
+			ServerError(res, req, "failed to generate totp secret", err)
+			return
+		}
+		sealedSecret, err := auth.SealTOTPSecret(cfg.TOTPKey, user.ID, secret)
+		if err != nil {
+			ServerError(res, req, "failed to encrypt totp secret", err)
+			return
+		}
+		secretParams := database.SetUserTOTPSecretParams{
+			TotpSecret: sql.NullString{String: sealedSecret, Valid: true},
+			ID:         user.ID,
+		}
+		if err := cfg.DB.SetUserTOTPSecret(req.Context(), secretParams); err != nil {
+			ServerError(res, req, "failed to store totp secret", err)
+			return
+		}
+		payload := totpEnrollPayload{
+			Secret:     secret,
+			OTPAuthURI: auth.TOTPURI(TOTPIssuer, user.Email, secret),
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+func ConfirmTOTPHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := mfaCodePayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		if user.TotpEnabledAt.Valid || !user.TotpSecret.Valid {
+			WriteError(res, req, ErrNoPendingMFAEnrolment)
+			return
+		}
+		secret, err := auth.OpenTOTPSecret(cfg.TOTPKey, user.ID, user.TotpSecret.String)
+		if err != nil {
+			ServerError(res, req, "failed to decrypt totp secret", err)
+			return
+		}
+		step, ok := auth.ValidateTOTP(secret, params.Code, time.Now())
+		if !ok {
+			WriteError(res, req, ValidationError(invalidField("code", ErrInvalidMFACode.Detail)))
+			return
+		}
+		codes, err := auth.GenerateRecoveryCodes()
+		if err != nil {
+			ServerError(res, req, "failed to generate recovery codes", err)
+			return
+		}
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			// The confirmation code can't be used again to log in.
+			rows, err := q.UseTOTPStep(req.Context(), database.UseTOTPStepParams{Step: step, ID: user.ID})
+			if err != nil {
+				return fmt.Errorf("failed to record totp code: %w", err)
+			}
+			if rows != 1 {
+				return ValidationError(invalidField("code", ErrInvalidMFACode.Detail))
+			}
+			if err := q.DeleteRecoveryCodesByUser(req.Context(), user.ID); err != nil {
+				return fmt.Errorf("failed to reset recovery codes: %w", err)
+			}
+			for _, code := range codes {
+				codeParams := database.CreateRecoveryCodeParams{
+					CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(code)),
+					UserID:   user.ID,
+				}
+				if err := q.CreateRecoveryCode(req.Context(), codeParams); err != nil {
+					return fmt.Errorf("failed to store recovery codes: %w", err)
+				}
+			}
+			if err := q.EnableUserTOTP(req.Context(), user.ID); err != nil {
+				return err
+			}
+			if err := recordUserEvent(req.Context(), q, EventUserMFAEnabled, user.ID); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditMFAEnabled,
+				ActorID:    user.ID,
+				TargetType: AuditTargetUser,
+				TargetID:   user.ID,
+				Diff:       map[string]auditChange{"mfa_enabled": {From: false, To: true}},
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to enable two-factor authentication", err)
+			return
+		}
+		data, err := json.Marshal(recoveryCodesPayload{RecoveryCodes: codes})
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+func DisableTOTPHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := mfaCodePayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		if !user.TotpEnabledAt.Valid {
+			WriteError(res, req, ErrMFANotEnabled)
+			return
+		}
+		ok, err := checkSecondFactor(req.Context(), cfg, user, params.Code, params.RecoveryCode)
+		if err != nil {
+			ServerError(res, req, "failed to check authentication code", err)
+			return
+		}
+		if !ok {
+			WriteError(res, req, ErrInvalidMFACode)
+			return
+		}
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.DisableUserTOTP(req.Context(), user.ID); err != nil {
+				return err
+			}
+			if err := q.DeleteRecoveryCodesByUser(req.Context(), user.ID); err != nil {
+				return fmt.Errorf("failed to delete recovery codes: %w", err)
+			}
+			if err := recordUserEvent(req.Context(), q, EventUserMFADisabled, user.ID); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditMFADisabled,
+				ActorID:    user.ID,
+				TargetType: AuditTargetUser,
+				TargetID:   user.ID,
+				Diff:       map[string]auditChange{"mfa_enabled": {From: true, To: false}},
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to disable two-factor authentication", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
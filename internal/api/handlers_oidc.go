@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	MaxOIDCLoginDuration time.Duration = time.Minute * 10
+	OIDCCallbackPath     string        = "/auth/oidc/callback"
+	// OIDCStateCookie binds a login attempt to the browser that started it, so
+	// a callback link can't be used to log someone else into our account.
+	OIDCStateCookie string = "oidc_state"
+)
+
+var (
+	ErrInvalidOIDCState    = NewError(ErrBadRequest, "invalid_login_state", "invalid or expired login state")
+	ErrOIDCEmailUnverified = NewError(ErrForbidden, "oidc_email_unverified", "identity provider did not return a verified email")
+	ErrOIDCProviderError   = NewError(ErrBadRequest, "oidc_provider_error", "identity provider returned an error")
+	ErrOIDCAuthentication  = NewError(ErrUnauthorized, "oidc_authentication_failed", "failed to authenticate with identity provider")
+	ErrOIDCEmailConflict   = NewError(ErrConflict, "oidc_email_unverified_account", "an account with this email exists but is not verified")
+)
+
+func OIDCLoginHandler(cfg *Config) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if err := cfg.DB.DeleteExpiredOIDCLoginStates(req.Context(), time.Now()); err != nil {
+			ServerError(res, req, "failed to clean up login states", err)
+			return
+		}
+		state, err := auth.MakeSecureToken()
+		if err != nil {
+			ServerError(res, req, "failed to create login state", err)
+			return
+		}
+		nonce, err := auth.MakeSecureToken()
+		if err != nil {
+			ServerError(res, req, "failed to create login nonce", err)
+			return
+		}
+		verifier := auth.GenerateOIDCVerifier()
+		stateParams := database.CreateOIDCLoginStateParams{
+			StateHash:    auth.HashToken(state),
+			Nonce:        nonce,
+			CodeVerifier: verifier,
+			ExpiresAt:    time.Now().Add(MaxOIDCLoginDuration),
+		}
+		if err := cfg.DB.CreateOIDCLoginState(req.Context(), stateParams); err != nil {
+			ServerError(res, req, "failed to store login state", err)
+			return
+		}
+		// Lax still sends the cookie on the provider's top-level redirect back.
+		http.SetCookie(res, &http.Cookie{
+			Name:     OIDCStateCookie,
+			Value:    state,
+			Path:     OIDCCallbackPath,
+			MaxAge:   int(MaxOIDCLoginDuration.Seconds()),
+			HttpOnly: true,
+			Secure:   strings.HasPrefix(cfg.AppBaseURL, "https://"),
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(res, req, cfg.OIDC.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+	}
+}
+
+// OIDCCallbackHandler finishes the login the browser started, and sends it
+// back to the app with the session (or the MFA challenge, or the error) in the
+// fragment of the URL, which never reaches our logs or the provider's.
+func OIDCCallbackHandler(cfg *Config) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		http.SetCookie(res, &http.Cookie{
+			Name:     OIDCStateCookie,
+			Path:     OIDCCallbackPath,
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   strings.HasPrefix(cfg.AppBaseURL, "https://"),
+			SameSite: http.SameSiteLaxMode,
+		})
+		query := req.URL.Query()
+		if providerErr := query.Get("error"); providerErr != "" {
+			writeOIDCError(res, req, cfg, ErrOIDCProviderError.WithDetail(ErrOIDCProviderError.Detail+": "+providerErr))
+			return
+		}
+		state, code := query.Get("state"), query.Get("code")
+		var missing []FieldError
+		if state == "" {
+			missing = append(missing, requiredField("state"))
+		}
+		if code == "" {
+			missing = append(missing, requiredField("code"))
+		}
+		if len(missing) > 0 {
+			writeOIDCError(res, req, cfg, ValidationError(missing...))
+			return
+		}
+		cookie, err := req.Cookie(OIDCStateCookie)
+		if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+			writeOIDCError(res, req, cfg, ErrInvalidOIDCState)
+			return
+		}
+		// Deleting the state on read makes every login attempt single use.
+		loginState, err := cfg.DB.ConsumeOIDCLoginState(req.Context(), auth.HashToken(state))
+		if err != nil || loginState.ExpiresAt.Before(time.Now()) {
+			writeOIDCError(res, req, cfg, ErrInvalidOIDCState)
+			return
+		}
+		identity, err := cfg.OIDC.Exchange(req.Context(), code, loginState.Nonce, loginState.CodeVerifier)
+		if err != nil {
+			writeOIDCError(res, req, cfg, ErrOIDCAuthentication.Wrap(err))
+			return
+		}
+		user, err := resolveOIDCUser(req, cfg, identity)
+		if err != nil {
+			writeOIDCError(res, req, cfg, err)
+			return
+		}
+		if user.TotpEnabledAt.Valid {
+			mfaToken, err := newMFAChallenge(req.Context(), cfg, user)
+			if err != nil {
+				writeOIDCError(res, req, cfg, err)
+				return
+			}
+			redirectToApp(res, req, cfg, url.Values{"mfa_token": {mfaToken}})
+			return
+		}
+		session, err := loginSession(req, cfg, user, "oidc")
+		if err != nil {
+			writeOIDCError(res, req, cfg, err)
+			return
+		}
+		redirectToApp(res, req, cfg, url.Values{
+			"token":         {session.Token},
+			"refresh_token": {session.RefreshToken},
+		})
+	}
+}
+
+// redirectToApp sends the browser back to the app with values in the fragment.
+func redirectToApp(res http.ResponseWriter, req *http.Request, cfg *Config, values url.Values) {
+	res.Header().Set("Cache-Control", "no-store")
+	res.Header().Set("Referrer-Policy", "no-referrer")
+	http.Redirect(res, req, cfg.AppBaseURL+"/#"+values.Encode(), http.StatusFound)
+}
+
+// writeOIDCError hands domain errors to the app, which shows them. Internal
+// errors are logged and answered like anywhere else.
+func writeOIDCError(res http.ResponseWriter, req *http.Request, cfg *Config, err error) {
+	p := problemFor(err)
+	if p.Status >= http.StatusInternalServerError {
+		ServerError(res, req, "failed to log in with identity provider", err)
+		return
+	}
+	redirectToApp(res, req, cfg, url.Values{"error": {p.Code}, "error_description": {p.Detail}})
+}
+
+// resolveOIDCUser finds the local account for an external identity, linking
+// it to an existing account by verified email or creating one just in time.
+func resolveOIDCUser(req *http.Request, cfg *Config, identity *auth.OIDCIdentity) (database.User, error) {
+	ctx := req.Context()
+	identityParams := database.GetUserIdentityParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+	}
+	if user, linked, err := getLinkedUser(ctx, cfg, identityParams); linked || err != nil {
+		return user, err
+	}
+	if !identity.EmailVerified {
+		return database.User{}, ErrOIDCEmailUnverified
+	}
+	email, err := auth.NormalizeEmail(identity.Email)
+	if err != nil {
+		return database.User{}, ErrOIDCEmailUnverified
+	}
+	linkParams := database.CreateUserIdentityParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   email,
+	}
+	user, err := cfg.DB.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		// An unverified local account could have been registered by someone else.
+		if !user.EmailVerifiedAt.Valid {
+			return database.User{}, ErrOIDCEmailConflict
+		}
+		linkParams.UserID = user.ID
+		err = cfg.InTx(ctx, func(q database.Querier) error {
+			_, err := q.CreateUserIdentity(ctx, linkParams)
+			return err
+		})
+	case errors.Is(err, sql.ErrNoRows):
+		user, err = provisionOIDCUser(ctx, cfg, linkParams)
+	default:
+		return database.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	// A concurrent login with the same identity got to link it first.
+	if errors.Is(err, ErrDuplicate) {
+		if user, linked, err := getLinkedUser(ctx, cfg, identityParams); linked && err == nil {
+			return user, nil
+		}
+	}
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to link user identity: %w", err)
+	}
+	return user, nil
+}
+
+// getLinkedUser returns the account linked to an external identity, if any.
+func getLinkedUser(ctx context.Context, cfg *Config, params database.GetUserIdentityParams) (database.User, bool, error) {
+	linked, err := cfg.DB.GetUserIdentity(ctx, params)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, false, nil
+	}
+	if err != nil {
+		return database.User{}, false, fmt.Errorf("failed to get user identity: %w", err)
+	}
+	user, err := cfg.DB.GetUserByID(ctx, linked.UserID)
+	if err != nil {
+		return database.User{}, false, fmt.Errorf("failed to get linked user: %w", err)
+	}
+	return user, true, nil
+}
+
+// provisionOIDCUser creates an account just in time for an external identity,
+// together with the link to it. The random password can't be used to log in.
+func provisionOIDCUser(ctx context.Context, cfg *Config, linkParams database.CreateUserIdentityParams) (database.User, error) {
+	secret, err := auth.MakeSecureToken()
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to create user: %w", err)
+	}
+	hashedPassword, err := auth.HashPassword(secret)
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to create user: %w", err)
+	}
+	userParams := database.CreateVerifiedUserParams{
+		ID:       uuid.New().String(),
+		Email:    linkParams.Email,
+		Password: hashedPassword,
+	}
+	linkParams.UserID = userParams.ID
+	var user database.User
+	err = cfg.InTx(ctx, func(q database.Querier) error {
+		var err error
+		if user, err = q.CreateVerifiedUser(ctx, userParams); err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, q, userEvent(EventUserCreated, user)); err != nil {
+			return err
+		}
+		_, err = q.CreateUserIdentity(ctx, linkParams)
+		return err
+	})
+	return user, err
+}
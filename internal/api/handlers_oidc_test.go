@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/database"
+)
+
+func TestResolveOIDCUser(t *testing.T) {
+	cfg := newTestConfig(t)
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback", nil)
+	identity := &auth.OIDCIdentity{
+		Issuer:        "https://idp.example.com",
+		Subject:       "alice",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+	}
+	user, err := resolveOIDCUser(req, cfg, identity)
+	if err != nil {
+		t.Fatalf("failed to provision user: %v", err)
+	}
+	linked, err := cfg.DB.GetUserIdentity(context.Background(), database.GetUserIdentityParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+	})
+	if err != nil || linked.UserID != user.ID {
+		t.Fatalf("got identity %+v, error %v, want a link to %s", linked, err, user.ID)
+	}
+	again, err := resolveOIDCUser(req, cfg, identity)
+	if err != nil || again.ID != user.ID {
+		t.Errorf("logging in again: got user %s, error %v, want %s", again.ID, err, user.ID)
+	}
+}
@@ -0,0 +1,507 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/charlesaraya/video-manager-go/internal/mailer"
+	"github.com/google/uuid"
+)
+
+const (
+	OrgRoleOwner          string        = "owner"
+	OrgRoleEditor         string        = "editor"
+	OrgRoleViewer         string        = "viewer"
+	MaxInvitationDuration time.Duration = 7 * 24 * time.Hour
+	InvitationLinkPath    string        = "/accept-invitation"
+)
+
+var (
+	ErrNotOrganizationMember     = NewError(ErrNotFound, "not_organization_member", "not a member of this organization")
+	ErrInsufficientOrgRole       = NewError(ErrForbidden, "insufficient_organization_role", "insufficient organization role")
+	ErrInvalidInvitation         = NewError(ErrBadRequest, "invalid_invitation", "invalid or expired invitation")
+	ErrLastOwner                 = NewError(ErrConflict, "last_owner", "an organization needs at least one owner")
+	ErrOrganizationNotFound      = NewError(ErrNotFound, "organization_not_found", "failed to get organization")
+	ErrMemberNotFound            = NewError(ErrNotFound, "organization_member_not_found", "failed to get organization member")
+	ErrInvitationEmail           = NewError(ErrForbidden, "invitation_email_mismatch", "invitation was sent to another email")
+	ErrAlreadyOrganizationMember = NewError(ErrConflict, "already_organization_member", "already a member of this organization")
+)
+
+// orgRoleRank orders roles so that each one includes the permissions of the ones below it.
+var orgRoleRank = map[string]int{
+	OrgRoleViewer: 1,
+	OrgRoleEditor: 2,
+	OrgRoleOwner:  3,
+}
+
+type organizationResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+}
+
+type organizationPayload struct {
+	Name string `json:"name"`
+}
+
+type memberRolePayload struct {
+	Role string `json:"role"`
+}
+
+type invitationPayload struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type acceptInvitationPayload struct {
+	Token string `json:"token"`
+}
+
+func validOrgRole(role string) bool {
+	_, ok := orgRoleRank[role]
+	return ok
+}
+
+// ensurePersonalOrganization returns the organization every user owns on their
+// own, creating it on first use. It shares the user's id.
+func ensurePersonalOrganization(ctx context.Context, cfg *Config, user database.User) (database.Organization, error) {
+	org, err := cfg.DB.GetOrganization(ctx, user.ID)
+	if err == nil {
+		return org, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Organization{}, fmt.Errorf("failed to get personal organization: %w", err)
+	}
+	org, err = createOrganization(ctx, cfg, user.ID, user.Email, user.ID)
+	if err != nil {
+		return database.Organization{}, fmt.Errorf("failed to create personal organization: %w", err)
+	}
+	return org, nil
+}
+
+// createOrganization creates an organization along with its owner, so that
+// no organization is ever left without one.
+func createOrganization(ctx context.Context, cfg *Config, orgID, name, ownerID string) (database.Organization, error) {
+	var org database.Organization
+	err := cfg.InTx(ctx, func(q database.Querier) error {
+		var err error
+		org, err = q.CreateOrganization(ctx, database.CreateOrganizationParams{
+			ID:   orgID,
+			Name: name,
+		})
+		if err != nil {
+			return err
+		}
+		memberParams := database.AddOrganizationMemberParams{
+			OrganizationID: org.ID,
+			UserID:         ownerID,
+			Role:           OrgRoleOwner,
+		}
+		if err := q.AddOrganizationMember(ctx, memberParams); err != nil {
+			return fmt.Errorf("failed to add organization owner: %w", err)
+		}
+		return nil
+	})
+	return org, err
+}
+
+// authorizeOrganization checks that the user holds at least minRole in the
+// organization and writes the error response otherwise. Non-members get a 404
+// so organization ids can't be probed.
+func authorizeOrganization(res http.ResponseWriter, req *http.Request, cfg *Config, orgID string, userUUID uuid.UUID, minRole string) (database.OrganizationMember, bool) {
+	memberParams := database.GetOrganizationMemberParams{
+		OrganizationID: orgID,
+		UserID:         userUUID.String(),
+	}
+	member, err := cfg.DB.GetOrganizationMember(req.Context(), memberParams)
+	if errors.Is(err, sql.ErrNoRows) {
+		WriteError(res, req, ErrNotOrganizationMember)
+		return database.OrganizationMember{}, false
+	}
+	if err != nil {
+		ServerError(res, req, "failed to get organization membership", err)
+		return database.OrganizationMember{}, false
+	}
+	if orgRoleRank[member.Role] < orgRoleRank[minRole] {
+		WriteError(res, req, ErrInsufficientOrgRole)
+		return database.OrganizationMember{}, false
+	}
+	return member, true
+}
+
+// authorizeVideo loads the video named in the path and checks the user holds
+// at least minRole in the organization owning it.
+func authorizeVideo(res http.ResponseWriter, req *http.Request, cfg *Config, userUUID uuid.UUID, minRole string) (database.Video, bool) {
+	videoUUID, err := uuid.Parse(req.PathValue("videoID"))
+	if err != nil {
+		WriteError(res, req, ErrInvalidVideoID)
+		return database.Video{}, false
+	}
+	video, err := cfg.DB.GetVideo(req.Context(), videoUUID.String())
+	if err != nil {
+		WriteError(res, req, ErrVideoNotFound)
+		return database.Video{}, false
+	}
+	if _, ok := authorizeOrganization(res, req, cfg, video.OrganizationID, userUUID, minRole); !ok {
+		return database.Video{}, false
+	}
+	return video, true
+}
+
+func CreateOrganizationHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := organizationPayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		name := strings.TrimSpace(params.Name)
+		if name == "" {
+			WriteError(res, req, ValidationError(requiredField("name")))
+			return
+		}
+		org, err := createOrganization(req.Context(), cfg, uuid.New().String(), name, userUUID.String())
+		if err != nil {
+			ServerError(res, req, "failed to create organization", err)
+			return
+		}
+		data, err := json.Marshal(organizationResponse{
+			ID:        org.ID,
+			CreatedAt: org.CreatedAt,
+			UpdatedAt: org.UpdatedAt,
+			Name:      org.Name,
+			Role:      OrgRoleOwner,
+		})
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusCreated)
+		res.Write(data)
+	}
+}
+
+func GetOrganizationsHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		if _, err := ensurePersonalOrganization(req.Context(), cfg, user); err != nil {
+			ServerError(res, req, "failed to get personal organization", err)
+			return
+		}
+		orgs, err := cfg.DB.GetOrganizationsByMember(req.Context(), user.ID)
+		if err != nil {
+			ServerError(res, req, "failed to get organizations", err)
+			return
+		}
+		orgsPayload := make([]organizationResponse, 0, len(orgs))
+		for _, org := range orgs {
+			orgsPayload = append(orgsPayload, organizationResponse(org))
+		}
+		data, err := json.Marshal(orgsPayload)
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+func GetOrganizationMembersHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		orgID := req.PathValue("organizationID")
+		if _, ok := authorizeOrganization(res, req, cfg, orgID, userUUID, OrgRoleViewer); !ok {
+			return
+		}
+		members, err := cfg.DB.GetOrganizationMembers(req.Context(), orgID)
+		if err != nil {
+			ServerError(res, req, "failed to get organization members", err)
+			return
+		}
+		data, err := json.Marshal(members)
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+// keepsAnOwner fails with ErrLastOwner when the organization has no owner
+// left. It runs after the change in the same transaction, which is then
+// rolled back.
+func keepsAnOwner(ctx context.Context, q database.Querier, orgID string) error {
+	owners, err := q.CountOrganizationOwners(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to count organization owners: %w", err)
+	}
+	if owners == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func UpdateOrganizationMemberHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		orgID := req.PathValue("organizationID")
+		if _, ok := authorizeOrganization(res, req, cfg, orgID, userUUID, OrgRoleOwner); !ok {
+			return
+		}
+		params := memberRolePayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		if !validOrgRole(params.Role) {
+			WriteError(res, req, ValidationError(invalidField("role", "invalid organization role")))
+			return
+		}
+		member, err := cfg.DB.GetOrganizationMember(req.Context(), database.GetOrganizationMemberParams{
+			OrganizationID: orgID,
+			UserID:         req.PathValue("userID"),
+		})
+		if err != nil {
+			WriteError(res, req, ErrMemberNotFound)
+			return
+		}
+		roleParams := database.UpdateOrganizationMemberRoleParams{
+			Role:           params.Role,
+			OrganizationID: member.OrganizationID,
+			UserID:         member.UserID,
+		}
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.UpdateOrganizationMemberRole(req.Context(), roleParams); err != nil {
+				return err
+			}
+			if err := keepsAnOwner(req.Context(), q, member.OrganizationID); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditMemberRoleChanged,
+				ActorID:    userUUID.String(),
+				TargetType: AuditTargetOrganization,
+				TargetID:   member.OrganizationID,
+				Diff: map[string]any{
+					"user_id": member.UserID,
+					"role":    auditChange{From: member.Role, To: params.Role},
+				},
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to update organization member", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RemoveOrganizationMemberHandler lets owners remove anyone, and any member
+// leave the organization on their own.
+func RemoveOrganizationMemberHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		orgID := req.PathValue("organizationID")
+		targetID := req.PathValue("userID")
+		minRole := OrgRoleOwner
+		if targetID == userUUID.String() {
+			minRole = OrgRoleViewer
+		}
+		if _, ok := authorizeOrganization(res, req, cfg, orgID, userUUID, minRole); !ok {
+			return
+		}
+		member, err := cfg.DB.GetOrganizationMember(req.Context(), database.GetOrganizationMemberParams{
+			OrganizationID: orgID,
+			UserID:         targetID,
+		})
+		if err != nil {
+			WriteError(res, req, ErrMemberNotFound)
+			return
+		}
+		removeParams := database.RemoveOrganizationMemberParams{
+			OrganizationID: member.OrganizationID,
+			UserID:         member.UserID,
+		}
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.RemoveOrganizationMember(req.Context(), removeParams); err != nil {
+				return err
+			}
+			if err := keepsAnOwner(req.Context(), q, member.OrganizationID); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditMemberRemoved,
+				ActorID:    userUUID.String(),
+				TargetType: AuditTargetOrganization,
+				TargetID:   member.OrganizationID,
+				Diff: map[string]any{
+					"user_id": member.UserID,
+					"role":    auditChange{From: member.Role},
+				},
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to remove organization member", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func InviteOrganizationMemberHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		orgID := req.PathValue("organizationID")
+		if _, ok := authorizeOrganization(res, req, cfg, orgID, userUUID, OrgRoleOwner); !ok {
+			return
+		}
+		params := invitationPayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		email, err := auth.NormalizeEmail(params.Email)
+		if err != nil {
+			WriteError(res, req, ValidationError(invalidField("email", err.Error())))
+			return
+		}
+		if !validOrgRole(params.Role) {
+			WriteError(res, req, ValidationError(invalidField("role", "invalid organization role")))
+			return
+		}
+		org, err := cfg.DB.GetOrganization(req.Context(), orgID)
+		if err != nil {
+			WriteError(res, req, ErrOrganizationNotFound)
+			return
+		}
+		token, err := auth.MakeSecureToken()
+		if err != nil {
+			ServerError(res, req, "failed to create invitation token", err)
+			return
+		}
+		invitationParams := database.CreateOrganizationInvitationParams{
+			TokenHash:      auth.HashToken(token),
+			OrganizationID: org.ID,
+			Email:          email,
+			Role:           params.Role,
+			InvitedBy:      userUUID.String(),
+			ExpiresAt:      time.Now().Add(MaxInvitationDuration),
+		}
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.CreateOrganizationInvitation(req.Context(), invitationParams); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditMemberInvited,
+				ActorID:    userUUID.String(),
+				TargetType: AuditTargetOrganization,
+				TargetID:   org.ID,
+				Diff:       map[string]string{"email": email, "role": params.Role},
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to create invitation", err)
+			return
+		}
+		link := fmt.Sprintf("%s%s?token=%s", cfg.AppBaseURL, InvitationLinkPath, url.QueryEscape(token))
+		msg := mailer.Message{
+			To:      email,
+			Subject: fmt.Sprintf("You've been invited to %s", org.Name),
+			Body: fmt.Sprintf("You've been invited to join %s as %s.\n\n"+
+				"Follow this link within %s to accept:\n%s\n", org.Name, params.Role, MaxInvitationDuration, link),
+		}
+		if err := cfg.Mailer.Send(req.Context(), msg); err != nil {
+			slog.WarnContext(req.Context(), "failed to send invitation email", "error", err)
+		}
+		res.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// AcceptInvitationHandler adds the user to the organization they were invited
+// to. The invitation must have been sent to the user's own email.
+func AcceptInvitationHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := acceptInvitationPayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		tokenHash := auth.HashToken(params.Token)
+		invitation, err := cfg.DB.GetOrganizationInvitation(req.Context(), tokenHash)
+		if err != nil || invitation.AcceptedAt.Valid || invitation.ExpiresAt.Before(time.Now()) {
+			WriteError(res, req, ErrInvalidInvitation)
+			return
+		}
+		if invitation.Email != user.Email {
+			WriteError(res, req, ErrInvitationEmail)
+			return
+		}
+		// Anyone can sign up with the invited address, only its owner can join.
+		if !user.EmailVerifiedAt.Valid {
+			WriteError(res, req, ErrEmailNotVerified)
+			return
+		}
+		_, err = cfg.DB.GetOrganizationMember(req.Context(), database.GetOrganizationMemberParams{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         user.ID,
+		})
+		if err == nil {
+			WriteError(res, req, ErrAlreadyOrganizationMember)
+			return
+		}
+		memberParams := database.AddOrganizationMemberParams{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         user.ID,
+			Role:           invitation.Role,
+		}
+		// The invitation is used up along with the membership it grants.
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			rows, err := q.AcceptOrganizationInvitation(req.Context(), tokenHash)
+			if err != nil {
+				return fmt.Errorf("failed to accept invitation: %w", err)
+			}
+			if rows != 1 {
+				return ErrInvalidInvitation
+			}
+			if err := q.AddOrganizationMember(req.Context(), memberParams); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditMemberJoined,
+				ActorID:    user.ID,
+				TargetType: AuditTargetOrganization,
+				TargetID:   invitation.OrganizationID,
+				Diff:       map[string]any{"role": auditChange{To: invitation.Role}},
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to add organization member", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
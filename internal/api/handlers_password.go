@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/charlesaraya/video-manager-go/internal/mailer"
+)
+
+var ErrInvalidResetToken = NewError(ErrBadRequest, "invalid_reset_token", "invalid or expired reset token")
+
+const (
+	MaxResetTokenDuration time.Duration = time.Hour
+	// PasswordResetMailTimeout bounds sending a reset email, which happens
+	// after the request has been answered.
+	PasswordResetMailTimeout time.Duration = time.Minute
+	PasswordResetLinkPath    string        = "/reset-password"
+	PasswordResetMailTitle   string        = "Reset your password"
+)
+
+type forgotPasswordPayload struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordPayload struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func ForgotPasswordHandler(cfg *Config) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := forgotPasswordPayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		email, err := auth.NormalizeEmail(params.Email)
+		if err != nil {
+			WriteError(res, req, ValidationError(invalidField("email", err.Error())))
+			return
+		}
+		// Always answer the same way, and right away, so that neither the
+		// response nor its timing can be used to probe accounts.
+		ctx := context.WithoutCancel(req.Context())
+		cfg.Background.Add(1)
+		go func() {
+			defer cfg.Background.Done()
+			ctx, cancel := context.WithTimeout(ctx, PasswordResetMailTimeout)
+			defer cancel()
+			if err := sendPasswordResetEmail(ctx, cfg, email); err != nil {
+				slog.WarnContext(ctx, "failed to send password reset email", "error", err)
+			}
+		}()
+		res.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// sendPasswordResetEmail mails a reset link to the account of email, if
+// there's one.
+func sendPasswordResetEmail(ctx context.Context, cfg *Config, email string) error {
+	user, err := cfg.DB.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	token, err := auth.MakeSecureToken()
+	if err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+	resetTokenParams := database.CreatePasswordResetTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(MaxResetTokenDuration),
+	}
+	if _, err := cfg.DB.CreatePasswordResetToken(ctx, resetTokenParams); err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+	link := fmt.Sprintf("%s%s?token=%s", cfg.AppBaseURL, PasswordResetLinkPath, url.QueryEscape(token))
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: PasswordResetMailTitle,
+		Body: fmt.Sprintf("Someone asked to reset the password of your account.\n\n"+
+			"Follow this link within %s to choose a new one:\n%s\n\n"+
+			"If it wasn't you, you can ignore this email.\n", MaxResetTokenDuration, link),
+	}
+	return cfg.Mailer.Send(ctx, msg)
+}
+
+func ResetPasswordHandler(cfg *Config) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := resetPasswordPayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		var missing []FieldError
+		if params.Token == "" {
+			missing = append(missing, requiredField("token"))
+		}
+		if params.Password == "" {
+			missing = append(missing, requiredField("password"))
+		}
+		if len(missing) > 0 {
+			WriteError(res, req, ValidationError(missing...))
+			return
+		}
+		tokenHash := auth.HashToken(params.Token)
+		resetToken, err := cfg.DB.GetPasswordResetToken(req.Context(), tokenHash)
+		if err != nil || resetToken.UsedAt.Valid || resetToken.ExpiresAt.Before(time.Now()) {
+			WriteError(res, req, ErrInvalidResetToken)
+			return
+		}
+		hashedPassword, err := auth.HashPassword(params.Password)
+		if err != nil {
+			ServerError(res, req, "failed to hash password", err)
+			return
+		}
+		passwordParams := database.UpdateUserPasswordParams{
+			Password: hashedPassword,
+			ID:       resetToken.UserID,
+		}
+		// The token is consumed along with the password change, so that a
+		// failed update leaves it usable. Consuming is conditional on it
+		// being unused, so concurrent resets can't both win.
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			rows, err := q.UsePasswordResetToken(req.Context(), tokenHash)
+			if err != nil {
+				return fmt.Errorf("failed to consume reset token: %w", err)
+			}
+			if rows != 1 {
+				return ErrInvalidResetToken
+			}
+			if err := q.UpdateUserPassword(req.Context(), passwordParams); err != nil {
+				return err
+			}
+			if err := q.RevokeAllRefreshTokensByUser(req.Context(), resetToken.UserID); err != nil {
+				return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+			}
+			if err := recordUserEvent(req.Context(), q, EventUserPasswordChanged, resetToken.UserID); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditPasswordReset,
+				ActorID:    resetToken.UserID,
+				TargetType: AuditTargetUser,
+				TargetID:   resetToken.UserID,
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to update password", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -1,7 +1,10 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -11,11 +14,22 @@ import (
 )
 
 const (
-	ErrDecodeRequestBody    string        = "failed to decode request body"
 	ErrMarshalPayload       string        = "failed to marshal payload"
 	ErrMakeJWT              string        = "failed to make access JWT"
 	MaxSessionDuration      time.Duration = time.Hour * 24
 	MaxRefreshTokenDuration time.Duration = time.Hour * 24 * 60
+	RoleUser                string        = "user"
+	RoleAdmin               string        = "admin"
+)
+
+var (
+	ErrDecodeRequestBody    = NewError(ErrBadRequest, "invalid_body", "failed to decode request body")
+	ErrAccountDisabled      = NewError(ErrForbidden, "account_disabled", "account is disabled")
+	ErrInvalidCredentials   = NewError(ErrUnauthorized, "invalid_credentials", "Incorrect email or password")
+	ErrInvalidRefreshToken  = NewError(ErrUnauthorized, "invalid_refresh_token", "failed to get refresh token")
+	ErrInvalidAuthorization = NewError(ErrBadRequest, "invalid_authorization", "missing or malformed authorization header")
+	ErrUserNotFound         = NewError(ErrNotFound, "user_not_found", "failed to get user")
+	ErrEmailAlreadyInUse    = NewError(ErrConflict, "email_in_use", "email address is already in use")
 )
 
 type loginPayload struct {
@@ -23,10 +37,45 @@ type loginPayload struct {
 	Password string `json:"password"`
 }
 
+// userResponse is the public representation of a user. Never serialize
+// database.User directly, it carries the password hash.
+type userResponse struct {
+	ID                  string     `json:"id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	Email               string     `json:"email"`
+	EmailVerified       bool       `json:"email_verified"`
+	EmailVerifiedAt     *time.Time `json:"email_verified_at,omitempty"`
+	MFAEnabled          bool       `json:"mfa_enabled"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	Role                string     `json:"role"`
+	Disabled            bool       `json:"disabled"`
+}
+
 type userPayload struct {
-	User         database.User
-	Token        string `json:"token"`
-	RefreshToken string `json:"refresh_token"`
+	User         userResponse `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+}
+
+func newUserResponse(user database.User) userResponse {
+	response := userResponse{
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		MFAEnabled:    user.TotpEnabledAt.Valid,
+		Role:          user.Role,
+		Disabled:      user.DisabledAt.Valid,
+	}
+	if user.EmailVerifiedAt.Valid {
+		response.EmailVerifiedAt = &user.EmailVerifiedAt.Time
+	}
+	if user.DeletionScheduledAt.Valid {
+		response.DeletionScheduledAt = &user.DeletionScheduledAt.Time
+	}
+	return response
 }
 
 type tokenPayload struct {
@@ -38,40 +87,66 @@ func CreateUserHandler(cfg *Config) http.HandlerFunc {
 		params := loginPayload{}
 		decoder := json.NewDecoder(req.Body)
 		if err := decoder.Decode(&params); err != nil {
-			Error(res, ErrDecodeRequestBody, http.StatusBadRequest)
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		var missing []FieldError
+		if params.Email == "" {
+			missing = append(missing, requiredField("email"))
+		}
+		if params.Password == "" {
+			missing = append(missing, requiredField("password"))
+		}
+		if len(missing) > 0 {
+			WriteError(res, req, ValidationError(missing...))
+			return
+		}
+		email, err := auth.NormalizeEmail(params.Email)
+		if err != nil {
+			WriteError(res, req, ValidationError(invalidField("email", err.Error())))
 			return
 		}
-		if params.Password == "" || params.Email == "" {
-			Error(res, "Email and password are required", http.StatusBadRequest)
+		if _, err := cfg.DB.GetUserByEmail(req.Context(), email); err == nil {
+			WriteError(res, req, ErrEmailAlreadyInUse)
 			return
 		}
 		hashedPassword, err := auth.HashPassword(params.Password)
 		if err != nil {
-			Error(res, err.Error(), http.StatusInternalServerError)
+			ServerError(res, req, err.Error(), err)
 			return
 		}
 		if err := auth.CheckPasswordHash(hashedPassword, params.Password); err != nil {
-			Error(res, err.Error(), http.StatusUnauthorized)
+			ServerError(res, req, "failed to check password hash", err)
 			return
 		}
 		userUUID := uuid.New()
 		userParams := database.CreateUserParams{
 			ID:       userUUID.String(),
-			Email:    params.Email,
+			Email:    email,
 			Password: hashedPassword,
 		}
-		user, err := cfg.DB.CreateUser(req.Context(), userParams)
+		var user database.User
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			var err error
+			if user, err = q.CreateUser(req.Context(), userParams); err != nil {
+				return err
+			}
+			return recordEvent(req.Context(), q, userEvent(EventUserCreated, user))
+		})
 		if err != nil {
-			Error(res, "failed to create user", http.StatusInternalServerError)
+			ServerError(res, req, "failed to create user", err)
 			return
 		}
-		data, err := json.Marshal(user)
+		if err := sendVerificationEmail(req.Context(), cfg, user.ID, user.Email); err != nil {
+			slog.WarnContext(req.Context(), "failed to send verification email", "error", err)
+		}
+		data, err := json.Marshal(newUserResponse(user))
 		if err != nil {
-			Error(res, ErrMarshalPayload, http.StatusInternalServerError)
+			ServerError(res, req, ErrMarshalPayload, err)
 			return
 		}
-		res.WriteHeader(http.StatusCreated)
 		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusCreated)
 		res.Write(data)
 	}
 }
@@ -81,78 +156,164 @@ func LoginHandler(cfg *Config) http.HandlerFunc {
 		params := loginPayload{}
 		decoder := json.NewDecoder(req.Body)
 		if err := decoder.Decode(&params); err != nil {
-			Error(res, ErrDecodeRequestBody, http.StatusInternalServerError)
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
 			return
 		}
-		user, err := cfg.DB.GetUserByEmail(req.Context(), params.Email)
+		email, err := auth.NormalizeEmail(params.Email)
 		if err != nil {
-			Error(res, "Incorrect email or password", http.StatusUnauthorized)
-			return
-		}
-		if err := auth.CheckPasswordHash(user.Password, params.Password); err != nil {
-			Error(res, "Incorrect email or password", http.StatusUnauthorized)
+			WriteError(res, req, ErrInvalidCredentials)
 			return
 		}
-		userUUID, err := uuid.Parse(user.ID)
-		if err != nil {
-			Error(res, "failed to parse uuid", http.StatusInternalServerError)
+		ipKey, accountKey := loginThrottleKeys(cfg, req, email)
+		if wait := max(cfg.LoginThrottle.Check(ipKey), cfg.AccountThrottle.Check(accountKey)); wait > 0 {
+			TooManyRequests(res, wait)
 			return
 		}
-		jwt, err := auth.MakeJWT(userUUID, cfg.TokenSecret, MaxSessionDuration)
+		user, err := cfg.DB.GetUserByEmail(req.Context(), email)
 		if err != nil {
-			Error(res, ErrMakeJWT, http.StatusInternalServerError)
+			cfg.LoginThrottle.Failure(ipKey)
+			cfg.AccountThrottle.Failure(accountKey)
+			recordAttempt(req, cfg, auditEvent{
+				Action:     AuditLoginFailed,
+				TargetType: AuditTargetEmail,
+				TargetID:   email,
+			})
+			WriteError(res, req, ErrInvalidCredentials)
 			return
 		}
-		refreshToken, err := auth.MakeRefreshToken()
-		if err != nil {
-			Error(res, "failed to create refresh token", http.StatusInternalServerError)
+		if err := auth.CheckPasswordHash(user.Password, params.Password); err != nil {
+			cfg.LoginThrottle.Failure(ipKey)
+			cfg.AccountThrottle.Failure(accountKey)
+			recordAttempt(req, cfg, auditEvent{
+				Action:     AuditLoginFailed,
+				TargetType: AuditTargetUser,
+				TargetID:   user.ID,
+				Diff:       map[string]string{"method": "password"},
+			})
+			WriteError(res, req, ErrInvalidCredentials)
 			return
 		}
-		refereshTokensParams := database.CreateRefreshTokenParams{
-			Token:     refreshToken,
-			UserID:    user.ID,
-			ExpiresAt: time.Now().Add(MaxRefreshTokenDuration),
-		}
-		_, err = cfg.DB.CreateRefreshToken(req.Context(), refereshTokensParams)
-		if err != nil {
-			Error(res, "failed to create refresh token", http.StatusInternalServerError)
+		if user.TotpEnabledAt.Valid {
+			writeMFAChallenge(res, req, cfg, user)
 			return
 		}
-		payload := userPayload{
-			User:         user,
-			Token:        jwt,
-			RefreshToken: refreshToken,
-		}
-		data, err := json.Marshal(payload)
-		if err != nil {
-			Error(res, ErrMarshalPayload, http.StatusInternalServerError)
-			return
+		// The address keeps its failures, or logging into an account of
+		// one's own between guesses would reset them.
+		cfg.AccountThrottle.Success(accountKey)
+		writeLogin(res, req, cfg, user, "password")
+	}
+}
+
+// loginThrottleKeys returns the throttle keys of a login attempt: the client
+// address, and the account whichever address it is tried from. Accounts back
+// off on their own throttle, which allows more failures before locking, so
+// that guesses spread across many addresses slow down without anyone being
+// able to lock an account out with a handful of wrong passwords.
+func loginThrottleKeys(cfg *Config, req *http.Request, email string) (ipKey, accountKey string) {
+	return "ip:" + clientIP(cfg, req), accountThrottleKey(email)
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + email
+}
+
+// writeLogin starts a session for user and answers with it.
+func writeLogin(res http.ResponseWriter, req *http.Request, cfg *Config, user database.User, method string) {
+	session, err := loginSession(req, cfg, user, method)
+	if err != nil {
+		ServerError(res, req, "failed to create session", err)
+		return
+	}
+	writeSession(res, req, session)
+}
+
+// writeSession answers with session.
+func writeSession(res http.ResponseWriter, req *http.Request, session userPayload) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		ServerError(res, req, ErrMarshalPayload, err)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(data)
+}
+
+// loginSession starts a session for user, and records the login with method
+// along with its refresh token.
+func loginSession(req *http.Request, cfg *Config, user database.User, method string) (userPayload, error) {
+	var session userPayload
+	err := cfg.InTx(req.Context(), func(q database.Querier) error {
+		var err error
+		if session, err = newSession(req.Context(), cfg, q, user); err != nil {
+			return err
 		}
-		res.Header().Set("Content-Type", "application/json")
-		res.Write(data)
+		return recordLogin(req, cfg, q, user, method)
+	})
+	return session, err
+}
+
+// newSession issues a fresh access JWT and refresh token pair for user, the
+// refresh token being stored with q.
+func newSession(ctx context.Context, cfg *Config, q database.Querier, user database.User) (userPayload, error) {
+	if user.DisabledAt.Valid {
+		return userPayload{}, ErrAccountDisabled
 	}
+	userUUID, err := uuid.Parse(user.ID)
+	if err != nil {
+		return userPayload{}, fmt.Errorf("failed to parse uuid: %w", err)
+	}
+	jwt, err := auth.MakeJWT(userUUID, cfg.TokenKeys, MaxSessionDuration)
+	if err != nil {
+		return userPayload{}, fmt.Errorf("%s: %w", ErrMakeJWT, err)
+	}
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		return userPayload{}, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	refereshTokensParams := database.CreateRefreshTokenParams{
+		Token:     refreshToken,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(MaxRefreshTokenDuration),
+	}
+	if _, err := q.CreateRefreshToken(ctx, refereshTokensParams); err != nil {
+		return userPayload{}, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return userPayload{
+		User:         newUserResponse(user),
+		Token:        jwt,
+		RefreshToken: refreshToken,
+	}, nil
 }
 
 func RefreshTokenHandler(cfg *Config) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
 		token, err := auth.GetBearerToken(req.Header)
 		if err != nil {
-			Error(res, err.Error(), http.StatusBadRequest)
+			WriteError(res, req, ErrInvalidAuthorization.Wrap(err))
 			return
 		}
 		refreshToken, err := cfg.DB.GetRefreshToken(req.Context(), token)
 		if err != nil || refreshToken.ExpiresAt.Before(time.Now()) || refreshToken.RevokedAt.Valid {
-			Error(res, "failed to get refresh token", http.StatusUnauthorized)
+			WriteError(res, req, ErrInvalidRefreshToken)
+			return
+		}
+		user, err := cfg.DB.GetUserByID(req.Context(), refreshToken.UserID)
+		if err != nil {
+			WriteError(res, req, ErrInvalidRefreshToken)
+			return
+		}
+		if user.DisabledAt.Valid {
+			WriteError(res, req, ErrAccountDisabled)
 			return
 		}
 		userUUID, err := uuid.Parse(refreshToken.UserID)
 		if err != nil {
-			Error(res, "failed to parse uuid", http.StatusInternalServerError)
+			ServerError(res, req, "failed to parse uuid", err)
 			return
 		}
-		jwt, err := auth.MakeJWT(userUUID, cfg.TokenSecret, MaxSessionDuration)
+		jwt, err := auth.MakeJWT(userUUID, cfg.TokenKeys, MaxSessionDuration)
 		if err != nil {
-			Error(res, ErrMakeJWT, http.StatusUnauthorized)
+			ServerError(res, req, ErrMakeJWT, err)
 			return
 		}
 		payload := tokenPayload{
@@ -160,7 +321,8 @@ func RefreshTokenHandler(cfg *Config) http.HandlerFunc {
 		}
 		data, err := json.Marshal(payload)
 		if err != nil {
-			Error(res, ErrMarshalPayload, http.StatusInternalServerError)
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
 		}
 		res.Header().Set("Content-Type", "application/json")
 		res.Write(data)
@@ -171,11 +333,34 @@ func RevokeTokenHandler(cfg *Config) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
 		token, err := auth.GetBearerToken(req.Header)
 		if err != nil {
-			Error(res, err.Error(), http.StatusBadRequest)
+			WriteError(res, req, ErrInvalidAuthorization.Wrap(err))
 			return
 		}
-		if err = cfg.DB.RevokeRefreshToken(req.Context(), token); err != nil {
-			Error(res, "failed to revoke refresh token", http.StatusInternalServerError)
+		refreshToken, err := cfg.DB.GetRefreshToken(req.Context(), token)
+		if err != nil || refreshToken.RevokedAt.Valid {
+			WriteError(res, req, ErrInvalidRefreshToken)
+			return
+		}
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			// Revoking is conditional on the token being live, so concurrent
+			// revocations are only recorded once.
+			rows, err := q.RevokeRefreshToken(req.Context(), token)
+			if err != nil {
+				return fmt.Errorf("failed to revoke refresh token: %w", err)
+			}
+			if rows != 1 {
+				return ErrInvalidRefreshToken
+			}
+			// The token itself is a credential, so the event only names its owner.
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditTokenRevoked,
+				ActorID:    refreshToken.UserID,
+				TargetType: AuditTargetUser,
+				TargetID:   refreshToken.UserID,
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to revoke refresh token", err)
 			return
 		}
 		res.WriteHeader(http.StatusNoContent)
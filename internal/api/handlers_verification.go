@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/charlesaraya/video-manager-go/internal/mailer"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidVerificationToken = NewError(ErrBadRequest, "invalid_verification_token", "invalid or expired verification token")
+	ErrEmailNotVerified         = NewError(ErrForbidden, "email_not_verified", "email address is not verified")
+	ErrEmailAlreadyVerified     = NewError(ErrConflict, "email_already_verified", "email address is already verified")
+)
+
+const (
+	MaxVerificationDuration time.Duration = time.Hour * 24 * 2
+	VerifyEmailLinkPath     string        = "/verify-email"
+	VerifyEmailMailTitle    string        = "Confirm your email address"
+)
+
+type verifyEmailPayload struct {
+	Token string `json:"token"`
+}
+
+func sendVerificationEmail(ctx context.Context, cfg *Config, userID, email string) error {
+	token, err := auth.MakeSecureToken()
+	if err != nil {
+		return err
+	}
+	tokenParams := database.CreateEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: time.Now().Add(MaxVerificationDuration),
+	}
+	if _, err := cfg.DB.CreateEmailVerificationToken(ctx, tokenParams); err != nil {
+		return fmt.Errorf("failed to create verification token: %w", err)
+	}
+	link := fmt.Sprintf("%s%s?token=%s", cfg.AppBaseURL, VerifyEmailLinkPath, url.QueryEscape(token))
+	msg := mailer.Message{
+		To:      email,
+		Subject: VerifyEmailMailTitle,
+		Body: fmt.Sprintf("Please confirm that %s is your email address by following this link:\n%s\n\n"+
+			"The link expires in %s.\n", email, link, MaxVerificationDuration),
+	}
+	return cfg.Mailer.Send(ctx, msg)
+}
+
+func VerifyEmailHandler(cfg *Config) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := verifyEmailPayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		tokenHash := auth.HashToken(params.Token)
+		verificationToken, err := cfg.DB.GetEmailVerificationToken(req.Context(), tokenHash)
+		if err != nil || verificationToken.UsedAt.Valid || verificationToken.ExpiresAt.Before(time.Now()) {
+			WriteError(res, req, ErrInvalidVerificationToken)
+			return
+		}
+		// The token only proves ownership of the address it was sent to. It is
+		// consumed along with the verification, so that a failed update leaves
+		// it usable.
+		verifyParams := database.MarkUserEmailVerifiedParams{
+			ID:    verificationToken.UserID,
+			Email: verificationToken.Email,
+		}
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			rows, err := q.UseEmailVerificationToken(req.Context(), tokenHash)
+			if err != nil {
+				return fmt.Errorf("failed to consume verification token: %w", err)
+			}
+			if rows != 1 {
+				return ErrInvalidVerificationToken
+			}
+			if rows, err = q.MarkUserEmailVerified(req.Context(), verifyParams); err != nil {
+				return err
+			}
+			if rows != 1 {
+				return ErrInvalidVerificationToken
+			}
+			return recordUserEvent(req.Context(), q, EventUserEmailVerified, verificationToken.UserID)
+		})
+		if err != nil {
+			ServerError(res, req, "failed to verify email", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func ResendVerificationEmailHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+		if err != nil {
+			WriteError(res, req, ErrUserNotFound)
+			return
+		}
+		if user.EmailVerifiedAt.Valid {
+			WriteError(res, req, ErrEmailAlreadyVerified)
+			return
+		}
+		if err := sendVerificationEmail(req.Context(), cfg, user.ID, user.Email); err != nil {
+			ServerError(res, req, "failed to send verification email", err)
+			return
+		}
+		res.WriteHeader(http.StatusAccepted)
+	}
+}
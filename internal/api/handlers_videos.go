@@ -6,18 +6,31 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/charlesaraya/video-manager-go/internal/metrics"
+	"github.com/charlesaraya/video-manager-go/internal/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrInvalidVideoID   = NewError(ErrBadRequest, "invalid_video_id", "failed to parse video ID")
+	ErrVideoNotFound    = NewError(ErrNotFound, "video_not_found", "failed to get video")
+	ErrInvalidForm      = NewError(ErrBadRequest, "invalid_form", "failed to parse multipart form")
+	ErrInvalidMediaType = NewError(ErrUnsupportedMediaType, "unsupported_media_type", "invalid media type")
+	ErrVideoTitleTaken  = NewError(ErrConflict, "video_title_taken", "the organization already has a video with this title")
 )
 
 func AddVideoHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
@@ -25,55 +38,100 @@ func AddVideoHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
 		videoParams := database.CreateVideoParams{}
 		decoder := json.NewDecoder(req.Body)
 		if err := decoder.Decode(&videoParams); err != nil {
-			Error(res, ErrDecodeRequestBody, http.StatusInternalServerError)
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
 			return
 		}
+		userID := userUUID.String()
 		videoParams.ID = uuid.New().String()
-		videoParams.UserID = userUUID.String()
-		video, err := cfg.DB.CreateVideo(context.Background(), videoParams)
+		videoParams.UserID = &userID
+		// Videos without an explicit organization go to the uploader's personal one.
+		if videoParams.OrganizationID == "" {
+			user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+			if err != nil {
+				ServerError(res, req, "failed to get user", err)
+				return
+			}
+			org, err := ensurePersonalOrganization(req.Context(), cfg, user)
+			if err != nil {
+				ServerError(res, req, "failed to get personal organization", err)
+				return
+			}
+			videoParams.OrganizationID = org.ID
+		}
+		if _, ok := authorizeOrganization(res, req, cfg, videoParams.OrganizationID, userUUID, OrgRoleEditor); !ok {
+			return
+		}
+		var video database.Video
+		err := cfg.InTx(req.Context(), func(q database.Querier) error {
+			var err error
+			if video, err = q.CreateVideo(req.Context(), videoParams); err != nil {
+				return err
+			}
+			if err := recordEvent(req.Context(), q, videoEvent(EventVideoCreated, video)); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditVideoCreated,
+				ActorID:    userUUID.String(),
+				TargetType: AuditTargetVideo,
+				TargetID:   video.ID,
+				Diff: map[string]auditChange{
+					"title":           {To: video.Title},
+					"description":     {To: video.Description},
+					"organization_id": {To: video.OrganizationID},
+				},
+			})
+		})
+		if errors.Is(err, ErrDuplicate) {
+			WriteError(res, req, ErrVideoTitleTaken.Wrap(err))
+			return
+		}
 		if err != nil {
-			Error(res, "failed to get videos", http.StatusInternalServerError)
+			ServerError(res, req, "failed to create video", err)
 			return
 		}
 		data, err := json.Marshal(video)
 		if err != nil {
-			Error(res, ErrMarshalPayload, http.StatusInternalServerError)
+			ServerError(res, req, ErrMarshalPayload, err)
 			return
 		}
-		res.WriteHeader(http.StatusOK)
 		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
 		res.Write(data)
 	}
 }
 
-func GetVideoHandler(cfg *Config) http.HandlerFunc {
+func GetVideoHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
-		videoUUID, err := uuid.Parse(req.PathValue("videoID"))
-		if err != nil {
-			Error(res, "failed to parse video ID", http.StatusInternalServerError)
-			return
-		}
-		video, err := cfg.DB.GetVideo(context.Background(), videoUUID.String())
-		if err != nil {
-			Error(res, "failed to get video", http.StatusInternalServerError)
+		video, ok := authorizeVideo(res, req, cfg, userUUID, OrgRoleViewer)
+		if !ok {
 			return
 		}
 		data, err := json.Marshal(video)
 		if err != nil {
-			Error(res, ErrMarshalPayload, http.StatusInternalServerError)
+			ServerError(res, req, ErrMarshalPayload, err)
 			return
 		}
-		res.WriteHeader(http.StatusOK)
 		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
 		res.Write(data)
 	}
 }
 
 func GetAllVideosHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
-		videos, err := cfg.DB.GetVideosByUser(context.Background(), userUUID.String())
+		var videos []database.Video
+		var err error
+		if orgID := req.URL.Query().Get("organization_id"); orgID != "" {
+			if _, ok := authorizeOrganization(res, req, cfg, orgID, userUUID, OrgRoleViewer); !ok {
+				return
+			}
+			videos, err = cfg.DB.GetVideosByOrganization(req.Context(), orgID)
+		} else {
+			videos, err = cfg.DB.GetVideosByMember(req.Context(), userUUID.String())
+		}
 		if err != nil {
-			Error(res, "failed to get videos", http.StatusInternalServerError)
+			ServerError(res, req, "failed to get videos", err)
 			return
 		}
 		videosPayload := []database.Video{}
@@ -82,33 +140,38 @@ func GetAllVideosHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
 		}
 		data, err := json.Marshal(videosPayload)
 		if err != nil {
-			Error(res, ErrMarshalPayload, http.StatusInternalServerError)
+			ServerError(res, req, ErrMarshalPayload, err)
 			return
 		}
-		res.WriteHeader(http.StatusOK)
 		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
 		res.Write(data)
 	}
 }
 
 func DeleteVideoHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
-		videoUUID := req.PathValue("videoID")
-		video, err := cfg.DB.GetVideo(context.Background(), videoUUID)
+		video, ok := authorizeVideo(res, req, cfg, userUUID, OrgRoleEditor)
+		if !ok {
+			return
+		}
+		err := cfg.InTx(req.Context(), func(q database.Querier) error {
+			if err := q.DeleteVideo(req.Context(), video.ID); err != nil {
+				return err
+			}
+			if err := recordEvent(req.Context(), q, videoEvent(EventVideoDeleted, video)); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditVideoDeleted,
+				ActorID:    userUUID.String(),
+				TargetType: AuditTargetVideo,
+				TargetID:   video.ID,
+				Diff:       map[string]auditChange{"title": {From: video.Title}},
+			})
+		})
 		if err != nil {
-			Error(res, "failed to get video", http.StatusNotFound)
-			return
-		}
-		if video.UserID != userUUID.String() {
-			Error(res, "failed to delete video", http.StatusUnauthorized)
-			return
-		}
-		deleteParams := database.DeleteVideoParams{
-			ID:     videoUUID,
-			UserID: userUUID.String(),
-		}
-		if err := cfg.DB.DeleteVideo(context.Background(), deleteParams); err != nil {
-			Error(res, "failed to delete video", http.StatusInternalServerError)
+			ServerError(res, req, "failed to delete video", err)
 			return
 		}
 		res.WriteHeader(http.StatusNoContent)
@@ -117,24 +180,30 @@ func DeleteVideoHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
 
 func UploadThumbnailHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
-		videoUUID := req.PathValue("videoID")
-		const maxMemory = 10 << 20
-		req.ParseMultipartForm(maxMemory)
+		video, ok := authorizeVideo(res, req, cfg, userUUID, OrgRoleEditor)
+		if !ok {
+			return
+		}
+		req.Body = http.MaxBytesReader(res, req.Body, cfg.MaxThumbnailUploadSize)
+		if err := req.ParseMultipartForm(cfg.MaxThumbnailUploadSize); err != nil {
+			writeFormFileError(res, req, "thumbnail", err)
+			return
+		}
 
 		file, header, err := req.FormFile("thumbnail")
 		if err != nil {
-			Error(res, "failed to parse form file", http.StatusInternalServerError)
+			writeFormFileError(res, req, "thumbnail", err)
 			return
 		}
 		defer file.Close()
 
 		mediaType, _, err := mime.ParseMediaType(header.Header.Get("Content-Type"))
 		if err != nil {
-			Error(res, "failed to parse media", http.StatusInternalServerError)
+			WriteError(res, req, ErrInvalidMediaType.Wrap(err))
 			return
 		}
 		if mediaType != MimeTypeImageJPEG && mediaType != MimeTypeImagePNG {
-			Error(res, "invalid media type", http.StatusInternalServerError)
+			WriteError(res, req, ErrInvalidMediaType)
 			return
 		}
 		key := make([]byte, 32)
@@ -145,92 +214,119 @@ func UploadThumbnailHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
 		filePath := filepath.Join(cfg.AssetsDirPath, fileName)
 		thumbnailFile, err := os.Create(filePath)
 		if err != nil {
-			Error(res, "failed to create thumbnail file", http.StatusInternalServerError)
+			ServerError(res, req, "failed to create thumbnail file", err)
 			return
 		}
-		_, err = io.Copy(thumbnailFile, file)
+		written, err := io.Copy(thumbnailFile, file)
+		metrics.UploadBytes.WithLabelValues("thumbnail").Add(float64(written))
 		if err != nil {
-			Error(res, "failed to copy thumbnail file", http.StatusInternalServerError)
+			ServerError(res, req, "failed to copy thumbnail file", err)
 			return
 		}
+		previousThumbnailURL := video.ThumbnailUrl
 		videoParams := database.UpdateVideoThumbnailParams{
-			ID:           videoUUID,
+			ID:           video.ID,
 			ThumbnailUrl: cfg.AssetsBrowserURL + fileName,
 		}
-		video, err := cfg.DB.UpdateVideoThumbnail(context.Background(), videoParams)
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			var err error
+			if video, err = q.UpdateVideoThumbnail(req.Context(), videoParams); err != nil {
+				return err
+			}
+			if err := recordEvent(req.Context(), q, videoEvent(EventVideoThumbnailUpdated, video)); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditVideoUpdated,
+				ActorID:    userUUID.String(),
+				TargetType: AuditTargetVideo,
+				TargetID:   video.ID,
+				Diff:       map[string]auditChange{"thumbnail_url": {From: previousThumbnailURL, To: video.ThumbnailUrl}},
+			})
+		})
 		if err != nil {
-			Error(res, "failed to update thumbnail file", http.StatusInternalServerError)
+			ServerError(res, req, "failed to update thumbnail file", err)
 			return
 		}
 		payload, err := json.Marshal(video)
 		if err != nil {
-			Error(res, ErrMarshalPayload, http.StatusInternalServerError)
+			ServerError(res, req, ErrMarshalPayload, err)
 			return
 		}
-		res.WriteHeader(http.StatusOK)
+		res.Header().Set("Content-Type", "application/json")
 		res.Write(payload)
 	}
 }
 
+// writeFormFileError answers for an upload whose form file can't be read:
+// too large, missing or a malformed form.
+func writeFormFileError(res http.ResponseWriter, req *http.Request, field string, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		WriteError(res, req, err)
+	case errors.Is(err, http.ErrMissingFile):
+		WriteError(res, req, ValidationError(requiredField(field)))
+	default:
+		WriteError(res, req, ErrInvalidForm.Wrap(err))
+	}
+}
+
+// TempUploadPattern names the temp files uploads are staged in before processing.
+const TempUploadPattern string = "tubely-upload-*.mp4"
+
 func UploadVideosHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
-		const uploadLimit = 1 << 30
-		req.Body = http.MaxBytesReader(res, req.Body, uploadLimit)
-		req.ParseMultipartForm(uploadLimit)
-
-		videoUUID := req.PathValue("videoID")
-		video, err := cfg.DB.GetVideo(context.Background(), videoUUID)
-		if err != nil {
-			Error(res, "failed to get video from DB", http.StatusInternalServerError)
-			return
-		}
-		videoUserID, err := uuid.Parse(video.UserID)
-		if err != nil {
-			Error(res, "failed to parse the video user uuid", http.StatusInternalServerError)
+		req.Body = http.MaxBytesReader(res, req.Body, cfg.MaxVideoUploadSize)
+		if err := req.ParseMultipartForm(cfg.MaxVideoUploadSize); err != nil {
+			writeFormFileError(res, req, "video", err)
 			return
 		}
-		if videoUserID != userUUID {
-			Error(res, "failed to authorize video owner", http.StatusUnauthorized)
+
+		video, ok := authorizeVideo(res, req, cfg, userUUID, OrgRoleEditor)
+		if !ok {
 			return
 		}
 		file, header, err := req.FormFile("video")
 		if err != nil {
-			Error(res, "failed to get the video from the request", http.StatusInternalServerError)
+			writeFormFileError(res, req, "video", err)
 			return
 		}
 		defer file.Close()
 
 		mediaType, _, err := mime.ParseMediaType(header.Header.Get("Content-Type"))
 		if err != nil {
-			Error(res, "failed to parse media type", http.StatusInternalServerError)
+			WriteError(res, req, ErrInvalidMediaType.Wrap(err))
 			return
 		}
 		if mediaType != MimeTypeVideo {
-			Error(res, "invalid media type", http.StatusInternalServerError)
+			WriteError(res, req, ErrInvalidMediaType)
 			return
 		}
-		fileName := "tubely-upload.mp4"
-		tempFile, err := os.CreateTemp("", fileName)
+		tempFile, err := os.CreateTemp("", TempUploadPattern)
 		if err != nil {
-			Error(res, "failed to create temp file", http.StatusInternalServerError)
+			ServerError(res, req, "failed to create temp file", err)
 			return
 		}
-		defer os.Remove(fileName)
+		defer os.Remove(tempFile.Name())
 		defer tempFile.Close()
 
-		_, err = io.Copy(tempFile, file)
+		written, err := io.Copy(tempFile, file)
+		metrics.UploadBytes.WithLabelValues("video").Add(float64(written))
 		if err != nil {
-			Error(res, "failed to copy video file", http.StatusInternalServerError)
+			ServerError(res, req, "failed to copy video file", err)
 			return
 		}
 		_, err = tempFile.Seek(0, io.SeekStart)
 		if err != nil {
-			Error(res, "failed to reset read offset to beginning of file", http.StatusInternalServerError)
+			ServerError(res, req, "failed to reset read offset to beginning of file", err)
 			return
 		}
-		aspectRatio, err := getVideoAspectRatio(tempFile.Name())
+		// Subprocesses are tied to the request, so they get killed with it on
+		// client disconnect or when the server gives up draining on shutdown.
+		aspectRatio, err := getVideoAspectRatio(req.Context(), tempFile.Name())
 		if err != nil {
-			Error(res, "failed to get video aspect ratio", http.StatusInternalServerError)
+			ServerError(res, req, "failed to get video aspect ratio", err)
 			return
 		}
 		prefix := "other"
@@ -240,61 +336,91 @@ func UploadVideosHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
 		case "9:16":
 			prefix = "portrait"
 		}
-		tempFileName, err := processVideoForFastStart(tempFile.Name())
+		processedFileName, err := processVideoForFastStart(req.Context(), tempFile.Name())
 		if err != nil {
-			Error(res, "failed to process video for fast start", http.StatusInternalServerError)
+			ServerError(res, req, "failed to process video for fast start", err)
 			return
 		}
-		tempFile, err = os.Open(tempFileName)
+		defer os.Remove(processedFileName)
+		processedFile, err := os.Open(processedFileName)
 		if err != nil {
-			Error(res, "failed to open preprocessed temp file", http.StatusInternalServerError)
+			ServerError(res, req, "failed to open preprocessed temp file", err)
 			return
 		}
+		defer processedFile.Close()
 		key := make([]byte, 32)
 		rand.Read(key)
 		fileTag := base64.RawURLEncoding.EncodeToString(key)
 		mediaTypeSplit := strings.Split(mediaType, "/")
 		fileKeyName := fmt.Sprintf("%s/%s.%s", prefix, fileTag, mediaTypeSplit[1])
-		putObjectInputParams := s3.PutObjectInput{
-			Bucket:      &cfg.S3BucketName,
-			Key:         &fileKeyName,
-			Body:        tempFile,
-			ContentType: &mediaType,
-		}
-		_, err = cfg.S3Client.PutObject(context.Background(), &putObjectInputParams)
+		err = observeStorage(req.Context(), "put_object", func(ctx context.Context) error {
+			return cfg.Storage.Put(ctx, fileKeyName, processedFile, mediaType)
+		})
 		if err != nil {
-			Error(res, "failed to put the object into s3", http.StatusInternalServerError)
+			ServerError(res, req, "failed to store the video", err)
 			return
 		}
-		videoURL := fmt.Sprintf("%s/%s", cfg.S3CfDistribution, fileKeyName)
+		// The video is stored by now: pointing at it, or cleaning it up, must
+		// not be cut short by the client going away.
+		req = req.WithContext(context.WithoutCancel(req.Context()))
+		videoURL := cfg.Storage.URL(fileKeyName)
+		previousVideoURL := video.VideoUrl
 		videoParams := database.UpdateVideoUrlParams{
-			ID:       videoUUID,
+			ID:       video.ID,
 			VideoUrl: videoURL,
 		}
-		video, err = cfg.DB.UpdateVideoUrl(context.Background(), videoParams)
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			var err error
+			if video, err = q.UpdateVideoUrl(req.Context(), videoParams); err != nil {
+				return err
+			}
+			// Subscribers only hear of the upload once it's stored and can be
+			// played, so a failed one never leaves a dangling event behind.
+			if err := recordEvent(req.Context(), q, videoEvent(EventVideoUploaded, video)); err != nil {
+				return err
+			}
+			if err := recordEvent(req.Context(), q, videoEvent(EventVideoProcessed, video)); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditVideoUploaded,
+				ActorID:    userUUID.String(),
+				TargetType: AuditTargetVideo,
+				TargetID:   video.ID,
+				Diff:       map[string]auditChange{"video_url": {From: previousVideoURL, To: video.VideoUrl}},
+			})
+		})
 		if err != nil {
-			Error(res, "failed to upload video url", http.StatusInternalServerError)
+			// Nothing points at the stored video, so it doesn't outlive the
+			// failed update.
+			deleteErr := observeStorage(req.Context(), "delete_object", func(ctx context.Context) error {
+				return cfg.Storage.Delete(ctx, fileKeyName)
+			})
+			if deleteErr != nil {
+				slog.ErrorContext(req.Context(), "failed to delete orphaned video", "key", fileKeyName, "error", deleteErr)
+			}
+			ServerError(res, req, "failed to upload video url", err)
 			return
 		}
 		payload, err := json.Marshal(video)
 		if err != nil {
-			Error(res, ErrMarshalPayload, http.StatusInternalServerError)
+			ServerError(res, req, ErrMarshalPayload, err)
 			return
 		}
-		res.WriteHeader(http.StatusOK)
+		res.Header().Set("Content-Type", "application/json")
 		res.Write(payload)
 	}
 }
 
-func getVideoAspectRatio(filepath string) (string, error) {
+func getVideoAspectRatio(ctx context.Context, filepath string) (string, error) {
 	args := []string{"-v", "error", "-print_format", "json", "-show_streams", filepath}
 	// Prep command
-	cmd := exec.Command("ffprobe", args...)
+	cmd := exec.CommandContext(ctx, "ffprobe", args...)
 	// Prep buffer to capture stdout
 	buff := bytes.Buffer{}
 	cmd.Stdout = &buff
 	// Run command
-	err := cmd.Run()
+	err := runSubprocess(ctx, cmd)
 	if err != nil {
 		return "", err
 	}
@@ -311,18 +437,34 @@ func getVideoAspectRatio(filepath string) (string, error) {
 	return dim.Stream[0].AspectRatio, nil
 }
 
-func processVideoForFastStart(filepath string) (string, error) {
+func processVideoForFastStart(ctx context.Context, filepath string) (string, error) {
 	output_filepath := filepath + ".preprocessing"
 	args := []string{"-i", filepath, "-c", "copy", "-movflags", "faststart", "-f", "mp4", output_filepath}
 	// Prep command
-	cmd := exec.Command("ffmpeg", args...)
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
 	// Prep buffer to capture stdout
 	buff := bytes.Buffer{}
 	cmd.Stdout = &buff
 	// Run command
-	err := cmd.Run()
+	err := runSubprocess(ctx, cmd)
 	if err != nil {
+		// A killed ffmpeg leaves a partial output behind.
+		os.Remove(output_filepath)
 		return "", err
 	}
 	return output_filepath, nil
 }
+
+// runSubprocess runs cmd in its own span and records its duration and outcome.
+func runSubprocess(ctx context.Context, cmd *exec.Cmd) error {
+	name := filepath.Base(cmd.Path)
+	_, span := tracing.Start(ctx, "exec."+name,
+		attribute.String("process.command", name),
+		attribute.StringSlice("process.command_args", cmd.Args[1:]),
+	)
+	start := time.Now()
+	err := cmd.Run()
+	metrics.ObserveSubprocess(name, start, err)
+	tracing.End(span, err)
+	return err
+}
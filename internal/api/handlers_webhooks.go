@@ -0,0 +1,491 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/google/uuid"
+)
+
+// WebhookSecretPrefix marks webhook signing secrets so they're recognisable
+// when they leak.
+const WebhookSecretPrefix string = "whsec_"
+
+var (
+	ErrWebhookNotFound         = NewError(ErrNotFound, "webhook_not_found", "failed to get webhook")
+	ErrWebhookDeliveryNotFound = NewError(ErrNotFound, "webhook_delivery_not_found", "failed to get webhook delivery")
+)
+
+type webhookResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	// Secret is only shown once, when the webhook is created.
+	Secret string `json:"secret,omitempty"`
+}
+
+func newWebhookResponse(sub database.WebhookSubscription) webhookResponse {
+	return webhookResponse{
+		ID:        sub.ID,
+		CreatedAt: sub.CreatedAt,
+		UpdatedAt: sub.UpdatedAt,
+		URL:       sub.Url,
+		Events:    webhookEvents(sub),
+		Active:    sub.Active,
+	}
+}
+
+type webhookDeliveryResponse struct {
+	ID            string          `json:"id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	Status        string          `json:"status"`
+	Attempts      int64           `json:"attempts"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	LastAttemptAt *time.Time      `json:"last_attempt_at,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	// Log lists every attempt, it's only included for a single delivery.
+	Log []webhookAttemptResponse `json:"log,omitempty"`
+}
+
+func newWebhookDeliveryResponse(delivery database.WebhookDelivery) webhookDeliveryResponse {
+	response := webhookDeliveryResponse{
+		ID:        delivery.ID,
+		CreatedAt: delivery.CreatedAt,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType,
+		Status:    delivery.Status,
+		Attempts:  delivery.Attempts,
+		Payload:   json.RawMessage(delivery.Payload),
+	}
+	if delivery.Status == WebhookStatusPending {
+		response.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	if delivery.LastAttemptAt.Valid {
+		response.LastAttemptAt = &delivery.LastAttemptAt.Time
+	}
+	return response
+}
+
+type webhookAttemptResponse struct {
+	CreatedAt      time.Time `json:"created_at"`
+	ResponseStatus *int64    `json:"response_status"`
+	ResponseBody   string    `json:"response_body"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int64     `json:"duration_ms"`
+}
+
+type webhookPayload struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+type updateWebhookPayload struct {
+	URL    *string   `json:"url"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active"`
+}
+
+// validateWebhookURL only accepts absolute http and https URLs of hosts that
+// resolve to public addresses.
+func validateWebhookURL(ctx context.Context, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ValidationError(requiredField("url"))
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", ValidationError(invalidField("url", "must be an absolute http or https URL"))
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", parsed.Hostname())
+	if err != nil {
+		return "", ValidationError(invalidField("url", "host can't be resolved"))
+	}
+	for _, ip := range ips {
+		if internalAddress(ip) {
+			return "", ValidationError(invalidField("url", "must not point at a loopback, link-local or private address"))
+		}
+	}
+	return parsed.String(), nil
+}
+
+// validateWebhookEvents returns the event filter as stored, sorted and
+// without duplicates.
+func validateWebhookEvents(events []string) (string, error) {
+	for _, event := range events {
+		if !slices.Contains(WebhookEventTypes, event) {
+			detail := fmt.Sprintf("unknown event %q, expected one of %s", event, strings.Join(WebhookEventTypes, ", "))
+			return "", ValidationError(invalidField("events", detail))
+		}
+	}
+	events = slices.Clone(events)
+	slices.Sort(events)
+	return strings.Join(slices.Compact(events), ","), nil
+}
+
+func newWebhookSecret() string {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return WebhookSecretPrefix + base64.RawURLEncoding.EncodeToString(key)
+}
+
+// authorizeWebhook loads the webhook named in the path. Webhooks of other
+// users are reported missing.
+func authorizeWebhook(res http.ResponseWriter, req *http.Request, cfg *Config, userUUID uuid.UUID) (database.WebhookSubscription, bool) {
+	webhookUUID, err := uuid.Parse(req.PathValue("webhookID"))
+	if err != nil {
+		WriteError(res, req, ErrWebhookNotFound)
+		return database.WebhookSubscription{}, false
+	}
+	subParams := database.GetWebhookSubscriptionParams{
+		ID:     webhookUUID.String(),
+		UserID: userUUID.String(),
+	}
+	sub, err := cfg.DB.GetWebhookSubscription(req.Context(), subParams)
+	if errors.Is(err, sql.ErrNoRows) {
+		WriteError(res, req, ErrWebhookNotFound)
+		return database.WebhookSubscription{}, false
+	}
+	if err != nil {
+		ServerError(res, req, "failed to get webhook", err)
+		return database.WebhookSubscription{}, false
+	}
+	return sub, true
+}
+
+func CreateWebhookHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := webhookPayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		webhookURL, err := validateWebhookURL(req.Context(), params.URL)
+		if err != nil {
+			WriteError(res, req, err)
+			return
+		}
+		events, err := validateWebhookEvents(params.Events)
+		if err != nil {
+			WriteError(res, req, err)
+			return
+		}
+		createParams := database.CreateWebhookSubscriptionParams{
+			ID:     uuid.New().String(),
+			UserID: userUUID.String(),
+			Url:    webhookURL,
+			Secret: newWebhookSecret(),
+			Events: events,
+		}
+		var sub database.WebhookSubscription
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			var err error
+			if sub, err = q.CreateWebhookSubscription(req.Context(), createParams); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditWebhookCreated,
+				ActorID:    userUUID.String(),
+				TargetType: AuditTargetWebhook,
+				TargetID:   sub.ID,
+				Diff: map[string]auditChange{
+					"url":    {To: sub.Url},
+					"events": {To: webhookEvents(sub)},
+				},
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to create webhook", err)
+			return
+		}
+		response := newWebhookResponse(sub)
+		response.Secret = sub.Secret
+		data, err := json.Marshal(response)
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusCreated)
+		res.Write(data)
+	}
+}
+
+func GetWebhooksHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		subs, err := cfg.DB.GetWebhookSubscriptionsByUser(req.Context(), userUUID.String())
+		if err != nil {
+			ServerError(res, req, "failed to get webhooks", err)
+			return
+		}
+		webhooksPayload := make([]webhookResponse, 0, len(subs))
+		for _, sub := range subs {
+			webhooksPayload = append(webhooksPayload, newWebhookResponse(sub))
+		}
+		data, err := json.Marshal(webhooksPayload)
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+func GetWebhookHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		sub, ok := authorizeWebhook(res, req, cfg, userUUID)
+		if !ok {
+			return
+		}
+		data, err := json.Marshal(newWebhookResponse(sub))
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+func UpdateWebhookHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		params := updateWebhookPayload{}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&params); err != nil {
+			WriteError(res, req, ErrDecodeRequestBody.Wrap(err))
+			return
+		}
+		sub, ok := authorizeWebhook(res, req, cfg, userUUID)
+		if !ok {
+			return
+		}
+		updateParams := database.UpdateWebhookSubscriptionParams{
+			Url:    sub.Url,
+			Events: sub.Events,
+			Active: sub.Active,
+			ID:     sub.ID,
+			UserID: sub.UserID,
+		}
+		var err error
+		if params.URL != nil {
+			if updateParams.Url, err = validateWebhookURL(req.Context(), *params.URL); err != nil {
+				WriteError(res, req, err)
+				return
+			}
+		}
+		if params.Events != nil {
+			if updateParams.Events, err = validateWebhookEvents(*params.Events); err != nil {
+				WriteError(res, req, err)
+				return
+			}
+		}
+		if params.Active != nil {
+			updateParams.Active = *params.Active
+		}
+		var updated database.WebhookSubscription
+		err = cfg.InTx(req.Context(), func(q database.Querier) error {
+			var err error
+			if updated, err = q.UpdateWebhookSubscription(req.Context(), updateParams); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditWebhookUpdated,
+				ActorID:    userUUID.String(),
+				TargetType: AuditTargetWebhook,
+				TargetID:   sub.ID,
+				Diff: map[string]auditChange{
+					"url":    {From: sub.Url, To: updated.Url},
+					"events": {From: webhookEvents(sub), To: webhookEvents(updated)},
+					"active": {From: sub.Active, To: updated.Active},
+				},
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to update webhook", err)
+			return
+		}
+		data, err := json.Marshal(newWebhookResponse(updated))
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+// DeleteWebhookHandler removes a webhook along with its queued deliveries and
+// their logs.
+func DeleteWebhookHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		sub, ok := authorizeWebhook(res, req, cfg, userUUID)
+		if !ok {
+			return
+		}
+		deleteParams := database.DeleteWebhookSubscriptionParams{
+			ID:     sub.ID,
+			UserID: sub.UserID,
+		}
+		err := cfg.InTx(req.Context(), func(q database.Querier) error {
+			if _, err := q.DeleteWebhookSubscription(req.Context(), deleteParams); err != nil {
+				return err
+			}
+			return recordAudit(req, cfg, q, auditEvent{
+				Action:     AuditWebhookDeleted,
+				ActorID:    userUUID.String(),
+				TargetType: AuditTargetWebhook,
+				TargetID:   sub.ID,
+				Diff:       map[string]auditChange{"url": {From: sub.Url}},
+			})
+		})
+		if err != nil {
+			ServerError(res, req, "failed to delete webhook", err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetWebhookDeliveriesHandler lists the deliveries of a webhook, newest first.
+func GetWebhookDeliveriesHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		limit, offset, err := parsePagination(req)
+		if err != nil {
+			WriteError(res, req, err)
+			return
+		}
+		sub, ok := authorizeWebhook(res, req, cfg, userUUID)
+		if !ok {
+			return
+		}
+		deliveries, err := cfg.DB.ListWebhookDeliveries(req.Context(), database.ListWebhookDeliveriesParams{
+			SubscriptionID: sub.ID,
+			Limit:          limit,
+			Offset:         offset,
+		})
+		if err != nil {
+			ServerError(res, req, "failed to get webhook deliveries", err)
+			return
+		}
+		deliveriesPayload := make([]webhookDeliveryResponse, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			deliveriesPayload = append(deliveriesPayload, newWebhookDeliveryResponse(delivery))
+		}
+		data, err := json.Marshal(deliveriesPayload)
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+// authorizeWebhookDelivery loads the delivery named in the path, which has to
+// belong to the webhook named there too.
+func authorizeWebhookDelivery(res http.ResponseWriter, req *http.Request, cfg *Config, userUUID uuid.UUID) (database.WebhookDelivery, bool) {
+	sub, ok := authorizeWebhook(res, req, cfg, userUUID)
+	if !ok {
+		return database.WebhookDelivery{}, false
+	}
+	deliveryUUID, err := uuid.Parse(req.PathValue("deliveryID"))
+	if err != nil {
+		WriteError(res, req, ErrWebhookDeliveryNotFound)
+		return database.WebhookDelivery{}, false
+	}
+	deliveryParams := database.GetWebhookDeliveryParams{
+		ID:             deliveryUUID.String(),
+		SubscriptionID: sub.ID,
+	}
+	delivery, err := cfg.DB.GetWebhookDelivery(req.Context(), deliveryParams)
+	if errors.Is(err, sql.ErrNoRows) {
+		WriteError(res, req, ErrWebhookDeliveryNotFound)
+		return database.WebhookDelivery{}, false
+	}
+	if err != nil {
+		ServerError(res, req, "failed to get webhook delivery", err)
+		return database.WebhookDelivery{}, false
+	}
+	return delivery, true
+}
+
+// GetWebhookDeliveryHandler answers with a delivery and the log of its attempts.
+func GetWebhookDeliveryHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		delivery, ok := authorizeWebhookDelivery(res, req, cfg, userUUID)
+		if !ok {
+			return
+		}
+		attempts, err := cfg.DB.GetWebhookDeliveryAttempts(req.Context(), delivery.ID)
+		if err != nil {
+			ServerError(res, req, "failed to get webhook delivery attempts", err)
+			return
+		}
+		response := newWebhookDeliveryResponse(delivery)
+		for _, attempt := range attempts {
+			logged := webhookAttemptResponse{
+				CreatedAt:    attempt.CreatedAt,
+				ResponseBody: attempt.ResponseBody,
+				Error:        attempt.Error,
+				DurationMs:   attempt.DurationMs,
+			}
+			if attempt.ResponseStatus.Valid {
+				logged.ResponseStatus = &attempt.ResponseStatus.Int64
+			}
+			response.Log = append(response.Log, logged)
+		}
+		data, err := json.Marshal(response)
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(data)
+	}
+}
+
+// RedeliverWebhookHandler queues a delivery again right away with a fresh set
+// of attempts, whatever its status. Its earlier attempts stay in the log.
+func RedeliverWebhookHandler(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		delivery, ok := authorizeWebhookDelivery(res, req, cfg, userUUID)
+		if !ok {
+			return
+		}
+		delivery, err := cfg.DB.RedeliverWebhookDelivery(req.Context(), database.RedeliverWebhookDeliveryParams{
+			NextAttemptAt:  time.Now(),
+			ID:             delivery.ID,
+			SubscriptionID: delivery.SubscriptionID,
+		})
+		if err != nil {
+			ServerError(res, req, "failed to redeliver webhook", err)
+			return
+		}
+		data, err := json.Marshal(newWebhookDeliveryResponse(delivery))
+		if err != nil {
+			ServerError(res, req, ErrMarshalPayload, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusAccepted)
+		res.Write(data)
+	}
+}
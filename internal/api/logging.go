@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/config"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RequestIDHeader    string = "X-Request-ID"
+	MaxRequestIDLength int    = 128
+	LogFormatJSON      string = config.LogFormatJSON
+	LogFormatText      string = config.LogFormatText
+)
+
+// requestInfo is shared by every handler serving a request, so that inner
+// handlers can enrich what the outer middlewares log.
+type requestInfo struct {
+	ID     string
+	UserID string
+	// ImpersonatorID is the admin acting as UserID, if any.
+	ImpersonatorID string
+}
+
+type requestInfoKey struct{}
+
+func requestInfoFrom(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
+
+// RequestID returns the id of the request ctx belongs to, if any.
+func RequestID(ctx context.Context) string {
+	if info := requestInfoFrom(ctx); info != nil {
+		return info.ID
+	}
+	return ""
+}
+
+// setRequestUser records the authenticated user of the request, and the admin
+// impersonating them if any, for logging and auditing.
+func setRequestUser(ctx context.Context, userID, impersonatorID string) {
+	if info := requestInfoFrom(ctx); info != nil {
+		info.UserID = userID
+		info.ImpersonatorID = impersonatorID
+	}
+}
+
+// requestImpersonator returns the admin impersonating the user of the request
+// ctx belongs to, if any.
+func requestImpersonator(ctx context.Context) string {
+	if info := requestInfoFrom(ctx); info != nil {
+		return info.ImpersonatorID
+	}
+	return ""
+}
+
+// validRequestID only accepts short, printable ids from clients so they can't
+// be used to forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > MaxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDMiddleware propagates the X-Request-ID of the incoming request, or
+// generates one, and echoes it back in the response.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
+		res.Header().Set(RequestIDHeader, id)
+		trace.SpanFromContext(req.Context()).SetAttributes(attribute.String("request_id", id))
+		ctx := context.WithValue(req.Context(), requestInfoKey{}, &requestInfo{ID: id})
+		next.ServeHTTP(res, req.WithContext(ctx))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(data)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// AccessLogMiddleware logs one line per request. It has to wrap the mux
+// directly to see the route pattern the request matched.
+func AccessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: res}
+		next.ServeHTTP(recorder, req)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(req.Context(), level, "request",
+			slog.String("method", req.Method),
+			slog.String("path", req.URL.Path),
+			slog.String("pattern", req.Pattern),
+			slog.Int("status", recorder.status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int64("bytes", recorder.bytes),
+			slog.String("remote_addr", req.RemoteAddr),
+		)
+	})
+}
+
+// contextHandler adds the request id, user and trace of the request being
+// served to every record logged with its context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info := requestInfoFrom(ctx); info != nil {
+		record.AddAttrs(slog.String("request_id", info.ID))
+		if info.UserID != "" {
+			record.AddAttrs(slog.String("user_id", info.UserID))
+		}
+		if info.ImpersonatorID != "" {
+			record.AddAttrs(slog.String("impersonator_id", info.ImpersonatorID))
+		}
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// NewLogger builds the process logger writing to stderr in format (json or
+// text) from level (debug, info, warn or error) up.
+func NewLogger(format, level string) (*slog.Logger, error) {
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	options := &slog.HandlerOptions{Level: logLevel}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case LogFormatJSON:
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	case LogFormatText:
+		handler = slog.NewTextHandler(os.Stderr, options)
+	default:
+		return nil, fmt.Errorf("invalid log format %q (%s or %s)", format, LogFormatJSON, LogFormatText)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
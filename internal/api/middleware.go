@@ -1,10 +1,20 @@
 package api
 
 import (
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/metrics"
+	"github.com/charlesaraya/video-manager-go/internal/ratelimit"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func CacheMiddleware(next http.Handler) http.Handler {
@@ -21,19 +31,164 @@ func NoCacheMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+var (
+	ErrInvalidAccessToken = NewError(ErrUnauthorized, "invalid_access_token", "failed to validate access jwt")
+	ErrAdminRequired      = NewError(ErrForbidden, "admin_required", "admin role required")
+)
+
 func AuthMiddleware(cfg *Config, handler func(*Config, uuid.UUID) http.HandlerFunc) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
 		jwt, err := auth.GetBearerToken(req.Header)
 		if err != nil {
-			Error(res, err.Error(), http.StatusBadRequest)
+			WriteError(res, req, ErrInvalidAuthorization.Wrap(err))
+			return
+		}
+		access, err := auth.ParseAccessJWT(jwt, cfg.TokenKeys)
+		if err != nil {
+			WriteError(res, req, ErrInvalidAccessToken)
 			return
 		}
-		userUUID, err := auth.ValidateJWT(jwt, cfg.TokenSecret)
+		// Disabling an account has to take effect before its access tokens expire.
+		user, err := cfg.DB.GetUserByID(req.Context(), access.UserID.String())
 		if err != nil {
-			Error(res, "failed to validate access jwt", http.StatusUnauthorized)
+			WriteError(res, req, ErrInvalidAccessToken)
 			return
 		}
+		if user.DisabledAt.Valid {
+			WriteError(res, req, ErrAccountDisabled)
+			return
+		}
+		impersonatorID := ""
+		if access.ActorID != uuid.Nil {
+			impersonatorID = access.ActorID.String()
+		}
+		setRequestUser(req.Context(), user.ID, impersonatorID)
 		// Call the original handler with injected userUUID
-		handler(cfg, userUUID).ServeHTTP(res, req)
+		handler(cfg, access.UserID).ServeHTTP(res, req)
+	}
+}
+
+// AdminMiddleware authenticates the request like AuthMiddleware and only lets
+// users with the admin role through.
+func AdminMiddleware(cfg *Config, handler func(*Config, uuid.UUID) http.HandlerFunc) http.HandlerFunc {
+	return AuthMiddleware(cfg, func(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+		return func(res http.ResponseWriter, req *http.Request) {
+			user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+			if err != nil {
+				WriteError(res, req, ErrInvalidAccessToken)
+				return
+			}
+			if user.Role != RoleAdmin {
+				WriteError(res, req, ErrAdminRequired)
+				return
+			}
+			handler(cfg, userUUID).ServeHTTP(res, req)
+		}
+	})
+}
+
+// RequireVerifiedEmail wraps an authenticated handler and rejects users whose
+// email isn't verified yet, when the server is configured to do so.
+func RequireVerifiedEmail(handler func(*Config, uuid.UUID) http.HandlerFunc) func(*Config, uuid.UUID) http.HandlerFunc {
+	return func(cfg *Config, userUUID uuid.UUID) http.HandlerFunc {
+		return func(res http.ResponseWriter, req *http.Request) {
+			if cfg.RequireVerifiedUploads {
+				user, err := cfg.DB.GetUserByID(req.Context(), userUUID.String())
+				if err != nil {
+					WriteError(res, req, ErrInvalidAccessToken)
+					return
+				}
+				if !user.EmailVerifiedAt.Valid {
+					WriteError(res, req, ErrEmailNotVerified)
+					return
+				}
+			}
+			handler(cfg, userUUID).ServeHTTP(res, req)
+		}
+	}
+}
+
+func RateLimitMiddleware(cfg *Config, limiter *ratelimit.Limiter, next http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if ok, wait := limiter.Allow(clientIP(cfg, req)); !ok {
+			TooManyRequests(res, wait)
+			return
+		}
+		next.ServeHTTP(res, req)
 	}
 }
+
+func TooManyRequests(res http.ResponseWriter, wait time.Duration) {
+	res.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	Error(res, "too many requests, retry later", http.StatusTooManyRequests)
+}
+
+// clientIP returns the address of the caller. Proxy headers are only honoured
+// when the server is configured to sit behind a trusted proxy, and only the
+// X-Forwarded-For entry that proxy appended: the ones before it come from the
+// client, which can make them up.
+func clientIP(cfg *Config, req *http.Request) string {
+	if cfg.TrustProxyHeaders {
+		if values := req.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			forwarded := values[len(values)-1]
+			if i := strings.LastIndex(forwarded, ","); i >= 0 {
+				forwarded = forwarded[i+1:]
+			}
+			if forwarded = strings.TrimSpace(forwarded); forwarded != "" {
+				return forwarded
+			}
+		}
+		if realIP := req.Header.Get("X-Real-IP"); realIP != "" {
+			return realIP
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// TracingMiddleware starts a server span per request, continuing the trace of
+// the caller when it sends a W3C traceparent header.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.request")
+}
+
+// RouteSpanMiddleware names the request span after the route pattern matched
+// by the mux, so spans group like metrics do. It has to sit right around the
+// mux as well.
+func RouteSpanMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(res, req)
+		if req.Pattern == "" {
+			return
+		}
+		route := req.Pattern
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = path
+		}
+		span := trace.SpanFromContext(req.Context())
+		span.SetName(req.Method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+	})
+}
+
+// MetricsMiddleware counts and times requests per route pattern. Like
+// AccessLogMiddleware, it has to sit right around the mux.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: res}
+		next.ServeHTTP(recorder, req)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		route := req.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequests.WithLabelValues(req.Method, route, strconv.Itoa(recorder.status)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(req.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charlesaraya/video-manager-go/internal/ratelimit"
+)
+
+func TestClientIPIgnoresSpoofedForwardedFor(t *testing.T) {
+	cfg := &Config{TrustProxyHeaders: true}
+	tests := []struct {
+		name      string
+		forwarded []string
+		want      string
+	}{
+		{"proxy entry only", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"spoofed leading entry", []string{"198.51.100.1, 203.0.113.7"}, "203.0.113.7"},
+		{"spoofed header before the proxy's", []string{"198.51.100.1", "203.0.113.7"}, "203.0.113.7"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, value := range test.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if got := clientIP(cfg, req); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddlewareBucketsBySpoofedForwardedFor(t *testing.T) {
+	cfg := &Config{TrustProxyHeaders: true}
+	handler := RateLimitMiddleware(cfg, ratelimit.NewLimiter(0.001, 1), func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusNoContent)
+	})
+	// A made-up leading address per request must not get a fresh bucket.
+	for i, spoofed := range []string{"198.51.100.1", "198.51.100.2"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Forwarded-For", spoofed+", 203.0.113.7")
+		res := httptest.NewRecorder()
+		handler(res, req)
+		want := http.StatusNoContent
+		if i > 0 {
+			want = http.StatusTooManyRequests
+		}
+		if res.Code != want {
+			t.Errorf("request %d: got status %d, want %d", i+1, res.Code, want)
+		}
+	}
+}
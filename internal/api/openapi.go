@@ -0,0 +1,19 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPIDocument describes every route registered in main.go, update it
+// along with the routes and their payloads.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// OpenAPIHandler serves the OpenAPI 3.1 document of the API.
+func OpenAPIHandler(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "max-age=300")
+	res.Write(openAPIDocument)
+}
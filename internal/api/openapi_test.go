@@ -0,0 +1,483 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/config"
+	"github.com/charlesaraya/video-manager-go/internal/mailer"
+	"github.com/charlesaraya/video-manager-go/internal/migrations"
+	"github.com/charlesaraya/video-manager-go/internal/ratelimit"
+	"github.com/charlesaraya/video-manager-go/internal/storage"
+	"github.com/google/uuid"
+)
+
+// openAPISpec checks requests and responses against openAPIDocument. It only
+// knows the parts of JSON Schema the document uses.
+type openAPISpec struct {
+	doc map[string]any
+}
+
+func loadOpenAPISpec(t *testing.T) *openAPISpec {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal(openAPIDocument, &doc); err != nil {
+		t.Fatalf("failed to parse openapi.json: %v", err)
+	}
+	return &openAPISpec{doc: doc}
+}
+
+// resolve follows node to what it references, if it's a reference.
+func (s *openAPISpec) resolve(node map[string]any) map[string]any {
+	ref, ok := node["$ref"].(string)
+	if !ok {
+		return node
+	}
+	var target any = s.doc
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		target = target.(map[string]any)[part]
+	}
+	return s.resolve(target.(map[string]any))
+}
+
+// operation returns the operation documented for method on the path of the
+// route pattern, such as "/api/videos/{videoID}".
+func (s *openAPISpec) operation(method, pattern string) (map[string]any, bool) {
+	item, ok := s.doc["paths"].(map[string]any)[pattern].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	op, ok := item[strings.ToLower(method)].(map[string]any)
+	return op, ok
+}
+
+// validate returns where value doesn't match schema, each prefixed with at.
+func (s *openAPISpec) validate(schema map[string]any, value any, at string) []string {
+	schema = s.resolve(schema)
+	var problems []string
+	fail := func(format string, args ...any) {
+		problems = append(problems, at+": "+fmt.Sprintf(format, args...))
+	}
+	if types := schemaTypes(schema["type"]); types != nil && !slices.Contains(types, jsonType(value)) {
+		// Integers are numbers too.
+		if !(jsonType(value) == "integer" && slices.Contains(types, "number")) {
+			fail("got %s, want %s", jsonType(value), strings.Join(types, " or "))
+			return problems
+		}
+	}
+	if want, ok := schema["const"]; ok && value != want {
+		fail("got %v, want %v", value, want)
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		fail("got %v, want one of %v", value, enum)
+	}
+	switch v := value.(type) {
+	case string:
+		switch schema["format"] {
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				fail("got %q, want a date-time", v)
+			}
+		case "uuid":
+			if _, err := uuid.Parse(v); err != nil {
+				fail("got %q, want a uuid", v)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				problems = append(problems, s.validate(items, item, fmt.Sprintf("%s[%d]", at, i))...)
+			}
+		}
+	case map[string]any:
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, property := range v {
+			if propertySchema, ok := properties[name].(map[string]any); ok {
+				problems = append(problems, s.validate(propertySchema, property, at+"."+name)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case map[string]any:
+				problems = append(problems, s.validate(additional, property, at+"."+name)...)
+			case bool:
+				if !additional {
+					fail("unexpected property %q", name)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+func schemaTypes(value any) []string {
+	switch t := value.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, len(t))
+		for i, item := range t {
+			types[i] = item.(string)
+		}
+		return types
+	}
+	return nil
+}
+
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// checkJSON validates the JSON in data against the schema of the media type
+// in content.
+func (s *openAPISpec) checkJSON(t *testing.T, content map[string]any, mediaType string, data []byte, at string) {
+	t.Helper()
+	media, ok := content[mediaType].(map[string]any)
+	if !ok {
+		t.Errorf("%s: %s isn't documented", at, mediaType)
+		return
+	}
+	schema, ok := media["schema"].(map[string]any)
+	if !ok || !strings.HasSuffix(mediaType, "json") {
+		return
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		t.Errorf("%s: invalid JSON: %v", at, err)
+		return
+	}
+	for _, problem := range s.validate(schema, value, at) {
+		t.Error(problem)
+	}
+}
+
+// openAPIClient sends requests to handlers routed as in main.go and checks
+// both ends of each exchange against the document.
+type openAPIClient struct {
+	t       *testing.T
+	spec    *openAPISpec
+	handler http.Handler
+}
+
+// do sends a JSON request to the route pattern, with its path values filled
+// from values in order, and returns the response once checked.
+func (c *openAPIClient) do(method, pattern, token string, body any, values ...string) *httptest.ResponseRecorder {
+	t := c.t
+	t.Helper()
+	op, ok := c.spec.operation(method, pattern)
+	if !ok {
+		t.Fatalf("%s %s isn't documented", method, pattern)
+	}
+	at := method + " " + pattern
+	path := pattern
+	for _, value := range values {
+		start, end := strings.Index(path, "{"), strings.Index(path, "}")
+		path = path[:start] + value + path[end+1:]
+	}
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatalf("%s: failed to marshal request: %v", at, err)
+		}
+		requestBody, ok := op["requestBody"].(map[string]any)
+		if !ok {
+			t.Fatalf("%s: request body isn't documented", at)
+		}
+		c.spec.checkJSON(t, c.spec.resolve(requestBody)["content"].(map[string]any), "application/json", data, at+" request")
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res := httptest.NewRecorder()
+	c.handler.ServeHTTP(res, req)
+
+	status := strconv.Itoa(res.Code)
+	at = fmt.Sprintf("%s response %s", at, status)
+	response, ok := op["responses"].(map[string]any)[status].(map[string]any)
+	if !ok {
+		t.Errorf("%s isn't documented, body: %s", at, res.Body)
+		return res
+	}
+	response = c.spec.resolve(response)
+	content, ok := response["content"].(map[string]any)
+	if !ok {
+		if res.Body.Len() > 0 {
+			t.Errorf("%s: got body %s, want none", at, res.Body)
+		}
+		return res
+	}
+	mediaType, _, err := mime.ParseMediaType(res.Header().Get("Content-Type"))
+	if err != nil {
+		t.Errorf("%s: invalid content type: %v", at, err)
+		return res
+	}
+	c.spec.checkJSON(t, content, mediaType, res.Body.Bytes(), at)
+	return res
+}
+
+// newTestConfig returns the config of a server with a fresh SQLite database
+// and every optional subsystem off.
+func newTestConfig(t *testing.T) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate signing key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("failed to marshal signing key: %v", err)
+	}
+	keyFile := filepath.Join(dir, "test"+auth.KeyFileExtension)
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write signing key: %v", err)
+	}
+	tokenKeys, err := auth.LoadKeySet(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to load signing key: %v", err)
+	}
+	blobs, err := storage.NewLocal(filepath.Join(dir, "media"), "http://localhost"+LocalStoragePath)
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	mail, err := mailer.NewLogMailer(filepath.Join(dir, "mail.log"))
+	if err != nil {
+		t.Fatalf("failed to create mailer: %v", err)
+	}
+	db := openTestDatabase(t, config.Database{
+		Driver: migrations.DriverSQLite,
+		Path:   filepath.Join(dir, "test.db"),
+	})
+	return &Config{
+		DB:                     db.Querier(),
+		DBConn:                 db.Conn,
+		InTx:                   db.InTx,
+		TokenKeys:              tokenKeys,
+		AppBaseURL:             "http://localhost",
+		Storage:                blobs,
+		Mailer:                 mail,
+		MaxVideoUploadSize:     1 << 20,
+		MaxThumbnailUploadSize: 1 << 20,
+		// Failed logins are only slowed down for a nanosecond, the next
+		// request of a test is already past it.
+		LoginThrottle:   ratelimit.NewThrottle(5, time.Nanosecond, time.Minute),
+		AccountThrottle: ratelimit.NewThrottle(20, time.Nanosecond, time.Minute),
+	}
+}
+
+func newOpenAPIClient(t *testing.T) *openAPIClient {
+	cfg := newTestConfig(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/openapi.json", OpenAPIHandler)
+	mux.HandleFunc("POST /api/users", CreateUserHandler(cfg))
+	mux.HandleFunc("POST /api/login", LoginHandler(cfg))
+	mux.HandleFunc("POST /api/refresh", RefreshTokenHandler(cfg))
+	mux.HandleFunc("POST /api/revoke", RevokeTokenHandler(cfg))
+	mux.HandleFunc("GET /api/users/me", AuthMiddleware(cfg, GetCurrentUserHandler))
+	mux.HandleFunc("GET /api/videos", AuthMiddleware(cfg, GetAllVideosHandler))
+	mux.HandleFunc("GET /api/videos/{videoID}", AuthMiddleware(cfg, GetVideoHandler))
+	mux.HandleFunc("POST /api/videos", AuthMiddleware(cfg, AddVideoHandler))
+	mux.HandleFunc("DELETE /api/videos/{videoID}", AuthMiddleware(cfg, DeleteVideoHandler))
+	mux.HandleFunc("POST /api/organizations", AuthMiddleware(cfg, CreateOrganizationHandler))
+	mux.HandleFunc("GET /api/organizations", AuthMiddleware(cfg, GetOrganizationsHandler))
+	mux.HandleFunc("PUT /api/organizations/{organizationID}/members/{userID}", AuthMiddleware(cfg, UpdateOrganizationMemberHandler))
+	mux.HandleFunc("DELETE /api/organizations/{organizationID}/members/{userID}", AuthMiddleware(cfg, RemoveOrganizationMemberHandler))
+	mux.HandleFunc("POST /api/webhooks", AuthMiddleware(cfg, CreateWebhookHandler))
+	mux.HandleFunc("GET /api/webhooks", AuthMiddleware(cfg, GetWebhooksHandler))
+	mux.HandleFunc("GET /api/webhooks/{webhookID}", AuthMiddleware(cfg, GetWebhookHandler))
+	return &openAPIClient{t: t, spec: loadOpenAPISpec(t), handler: RequestIDMiddleware(mux)}
+}
+
+// decode unmarshals the body of a checked response.
+func decode[T any](t *testing.T, res *httptest.ResponseRecorder) T {
+	t.Helper()
+	var payload T
+	if err := json.Unmarshal(res.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return payload
+}
+
+func TestOpenAPIDocumentIsServed(t *testing.T) {
+	c := newOpenAPIClient(t)
+	res := c.do(http.MethodGet, "/api/openapi.json", "", nil)
+	if !bytes.Equal(res.Body.Bytes(), openAPIDocument) {
+		t.Error("served document differs from openapi.json")
+	}
+}
+
+func TestOpenAPIAuthFlow(t *testing.T) {
+	c := newOpenAPIClient(t)
+	credentials := map[string]string{"email": "alice@example.com", "password": "correct horse"}
+	if res := c.do(http.MethodPost, "/api/users", "", credentials); res.Code != http.StatusCreated {
+		t.Fatalf("create user: got status %d, want %d", res.Code, http.StatusCreated)
+	}
+	if res := c.do(http.MethodPost, "/api/users", "", credentials); res.Code != http.StatusConflict {
+		t.Errorf("create user again: got status %d, want %d", res.Code, http.StatusConflict)
+	}
+	wrong := map[string]string{"email": credentials["email"], "password": "wrong"}
+	if res := c.do(http.MethodPost, "/api/login", "", wrong); res.Code != http.StatusUnauthorized {
+		t.Errorf("login with a wrong password: got status %d, want %d", res.Code, http.StatusUnauthorized)
+	}
+	res := c.do(http.MethodPost, "/api/login", "", credentials)
+	if res.Code != http.StatusOK {
+		t.Fatalf("login: got status %d, want %d", res.Code, http.StatusOK)
+	}
+	session := decode[userPayload](t, res)
+	if res := c.do(http.MethodGet, "/api/users/me", session.Token, nil); res.Code != http.StatusOK {
+		t.Errorf("get current user: got status %d, want %d", res.Code, http.StatusOK)
+	}
+	if res := c.do(http.MethodGet, "/api/users/me", "not-a-token", nil); res.Code != http.StatusUnauthorized {
+		t.Errorf("get current user with a bad token: got status %d, want %d", res.Code, http.StatusUnauthorized)
+	}
+	if res := c.do(http.MethodPost, "/api/refresh", session.RefreshToken, nil); res.Code != http.StatusOK {
+		t.Errorf("refresh: got status %d, want %d", res.Code, http.StatusOK)
+	}
+	if res := c.do(http.MethodPost, "/api/revoke", session.RefreshToken, nil); res.Code != http.StatusNoContent {
+		t.Errorf("revoke: got status %d, want %d", res.Code, http.StatusNoContent)
+	}
+	if res := c.do(http.MethodPost, "/api/revoke", session.RefreshToken, nil); res.Code != http.StatusUnauthorized {
+		t.Errorf("revoke again: got status %d, want %d", res.Code, http.StatusUnauthorized)
+	}
+	if res := c.do(http.MethodPost, "/api/refresh", session.RefreshToken, nil); res.Code != http.StatusUnauthorized {
+		t.Errorf("refresh with a revoked token: got status %d, want %d", res.Code, http.StatusUnauthorized)
+	}
+}
+
+// login registers a user and returns its access token.
+func (c *openAPIClient) login(email string) string {
+	c.t.Helper()
+	credentials := map[string]string{"email": email, "password": "correct horse"}
+	c.do(http.MethodPost, "/api/users", "", credentials)
+	res := c.do(http.MethodPost, "/api/login", "", credentials)
+	if res.Code != http.StatusOK {
+		c.t.Fatalf("login: got status %d, want %d", res.Code, http.StatusOK)
+	}
+	return decode[userPayload](c.t, res).Token
+}
+
+func TestOpenAPIVideos(t *testing.T) {
+	c := newOpenAPIClient(t)
+	token := c.login("alice@example.com")
+	draft := map[string]string{"title": "Title", "description": "Description"}
+	res := c.do(http.MethodPost, "/api/videos", token, draft)
+	if res.Code != http.StatusOK {
+		t.Fatalf("create video: got status %d, want %d", res.Code, http.StatusOK)
+	}
+	video := decode[map[string]any](t, res)
+	videoID, _ := video["id"].(string)
+	res = c.do(http.MethodPost, "/api/videos", token, draft)
+	if res.Code != http.StatusConflict {
+		t.Errorf("create video with a taken title: got status %d, want %d", res.Code, http.StatusConflict)
+	} else if p := decode[problem](t, res); p.Code != ErrVideoTitleTaken.Code {
+		t.Errorf("create video with a taken title: got code %q, want %q", p.Code, ErrVideoTitleTaken.Code)
+	}
+	if res := c.do(http.MethodGet, "/api/videos", token, nil); res.Code != http.StatusOK {
+		t.Errorf("list videos: got status %d, want %d", res.Code, http.StatusOK)
+	}
+	if res := c.do(http.MethodGet, "/api/videos/{videoID}", token, nil, videoID); res.Code != http.StatusOK {
+		t.Errorf("get video: got status %d, want %d", res.Code, http.StatusOK)
+	}
+	other := c.login("bob@example.com")
+	if res := c.do(http.MethodDelete, "/api/videos/{videoID}", other, nil, videoID); res.Code != http.StatusNotFound {
+		t.Errorf("delete video of another user: got status %d, want %d", res.Code, http.StatusNotFound)
+	}
+	if res := c.do(http.MethodDelete, "/api/videos/{videoID}", token, nil, videoID); res.Code != http.StatusNoContent {
+		t.Errorf("delete video: got status %d, want %d", res.Code, http.StatusNoContent)
+	}
+	if res := c.do(http.MethodGet, "/api/videos/{videoID}", token, nil, videoID); res.Code != http.StatusNotFound {
+		t.Errorf("get deleted video: got status %d, want %d", res.Code, http.StatusNotFound)
+	}
+}
+
+func TestOpenAPIOrganizations(t *testing.T) {
+	c := newOpenAPIClient(t)
+	token := c.login("alice@example.com")
+	res := c.do(http.MethodPost, "/api/organizations", token, map[string]string{"name": "Team"})
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create organization: got status %d, want %d", res.Code, http.StatusCreated)
+	}
+	org := decode[organizationResponse](t, res)
+	if res := c.do(http.MethodGet, "/api/organizations", token, nil); res.Code != http.StatusOK {
+		t.Errorf("list organizations: got status %d, want %d", res.Code, http.StatusOK)
+	}
+	userID := decode[userResponse](t, c.do(http.MethodGet, "/api/users/me", token, nil)).ID
+	// The only owner can neither step down nor leave.
+	member := "/api/organizations/{organizationID}/members/{userID}"
+	if res := c.do(http.MethodPut, member, token, memberRolePayload{Role: OrgRoleEditor}, org.ID, userID); res.Code != http.StatusConflict {
+		t.Errorf("demote the last owner: got status %d, want %d", res.Code, http.StatusConflict)
+	}
+	if res := c.do(http.MethodDelete, member, token, nil, org.ID, userID); res.Code != http.StatusConflict {
+		t.Errorf("remove the last owner: got status %d, want %d", res.Code, http.StatusConflict)
+	}
+	if res := c.do(http.MethodPut, member, token, memberRolePayload{Role: OrgRoleOwner}, org.ID, userID); res.Code != http.StatusNoContent {
+		t.Errorf("keep the last owner: got status %d, want %d", res.Code, http.StatusNoContent)
+	}
+}
+
+func TestOpenAPIWebhooks(t *testing.T) {
+	c := newOpenAPIClient(t)
+	token := c.login("alice@example.com")
+	// Addresses are checked without a DNS lookup when they're literal.
+	internal := map[string]any{"url": "http://127.0.0.1/hook", "events": []string{EventVideoCreated}}
+	if res := c.do(http.MethodPost, "/api/webhooks", token, internal); res.Code != http.StatusBadRequest {
+		t.Errorf("create webhook to a loopback address: got status %d, want %d", res.Code, http.StatusBadRequest)
+	}
+	public := map[string]any{"url": "https://203.0.113.10/hook", "events": []string{EventVideoCreated}}
+	res := c.do(http.MethodPost, "/api/webhooks", token, public)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create webhook: got status %d, want %d", res.Code, http.StatusCreated)
+	}
+	webhook := decode[map[string]any](t, res)
+	webhookID, _ := webhook["id"].(string)
+	if res := c.do(http.MethodGet, "/api/webhooks", token, nil); res.Code != http.StatusOK {
+		t.Errorf("list webhooks: got status %d, want %d", res.Code, http.StatusOK)
+	}
+	if res := c.do(http.MethodGet, "/api/webhooks/{webhookID}", token, nil, webhookID); res.Code != http.StatusOK {
+		t.Errorf("get webhook: got status %d, want %d", res.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/charlesaraya/video-manager-go/internal/events"
+	"github.com/charlesaraya/video-manager-go/internal/metrics"
+	"github.com/charlesaraya/video-manager-go/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	EventVideoCreated          string = "video.created"
+	EventVideoUploaded         string = "video.uploaded"
+	EventVideoProcessed        string = "video.processed"
+	EventVideoThumbnailUpdated string = "video.thumbnail_updated"
+	EventVideoDeleted          string = "video.deleted"
+
+	EventUserCreated           string = "user.created"
+	EventUserEmailChanged      string = "user.email_changed"
+	EventUserEmailVerified     string = "user.email_verified"
+	EventUserPasswordChanged   string = "user.password_changed"
+	EventUserMFAEnabled        string = "user.mfa_enabled"
+	EventUserMFADisabled       string = "user.mfa_disabled"
+	EventUserRoleChanged       string = "user.role_changed"
+	EventUserDisabled          string = "user.disabled"
+	EventUserEnabled           string = "user.enabled"
+	EventUserDeletionScheduled string = "user.deletion_scheduled"
+	EventUserDeletionCancelled string = "user.deletion_cancelled"
+	EventUserDeleted           string = "user.deleted"
+
+	EventAggregateVideo string = "video"
+	EventAggregateUser  string = "user"
+
+	OutboxQueue        string        = "outbox"
+	OutboxBatchSize    int64         = 50
+	MaxEventRetryDelay time.Duration = time.Hour
+)
+
+// domainEvent is a change to record in the outbox.
+type domainEvent struct {
+	Type          string
+	AggregateType string
+	AggregateID   string
+	// UserID owns the aggregate, webhooks of this user receive the event.
+	UserID string
+	Data   any
+}
+
+func videoEvent(eventType string, video database.Video) domainEvent {
+	event := domainEvent{
+		Type:          eventType,
+		AggregateType: EventAggregateVideo,
+		AggregateID:   video.ID,
+		Data:          video,
+	}
+	// Videos in shared organizations outlive the accounts that uploaded them.
+	if video.UserID != nil {
+		event.UserID = *video.UserID
+	}
+	return event
+}
+
+func userEvent(eventType string, user database.User) domainEvent {
+	return domainEvent{
+		Type:          eventType,
+		AggregateType: EventAggregateUser,
+		AggregateID:   user.ID,
+		UserID:        user.ID,
+		Data:          newUserResponse(user),
+	}
+}
+
+// recordEvent writes an event to the outbox. Given the queries of the
+// transaction making the change it describes, the event gets published if
+// and only if the change is committed.
+func recordEvent(ctx context.Context, q database.Querier, event domainEvent) error {
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", event.Type, err)
+	}
+	eventParams := database.CreateOutboxEventParams{
+		EventID:       uuid.New().String(),
+		EventType:     event.Type,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		UserID:        event.UserID,
+		Payload:       string(payload),
+		NextAttemptAt: time.Now(),
+	}
+	if err := q.CreateOutboxEvent(ctx, eventParams); err != nil {
+		return fmt.Errorf("failed to record %s event: %w", event.Type, err)
+	}
+	return nil
+}
+
+// recordUserEvent records an event carrying the user as the transaction
+// sees it, after the change.
+func recordUserEvent(ctx context.Context, q database.Querier, eventType, userID string) error {
+	user, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	return recordEvent(ctx, q, userEvent(eventType, user))
+}
+
+// RunOutboxDispatcher publishes the events recorded in the outbox to every
+// sink, retrying the ones that fail, and removes published events once they
+// are past retention. It blocks until ctx is cancelled.
+func RunOutboxDispatcher(ctx context.Context, cfg *Config) {
+	ticker := time.NewTicker(cfg.EventPollInterval)
+	defer ticker.Stop()
+	for {
+		// Full batches mean more events are probably waiting already.
+		for {
+			claimed, err := publishDueEvents(ctx, cfg)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to publish events", "error", err)
+			}
+			if err != nil || claimed < OutboxBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+		if pending, err := cfg.DB.CountPendingOutboxEvents(ctx); err == nil {
+			metrics.JobQueueDepth.WithLabelValues(OutboxQueue).Set(float64(pending))
+		}
+		publishedBefore := sql.NullTime{Time: time.Now().Add(-cfg.EventRetention), Valid: true}
+		if _, err := cfg.DB.DeletePublishedOutboxEvents(ctx, publishedBefore); err != nil {
+			slog.ErrorContext(ctx, "failed to delete published events", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDueEvents claims a batch of due events and publishes them one by one
+// in the order they were recorded. Claiming pushes them back by a lease long
+// enough to publish the whole batch, so they're only published again if this
+// process dies on the way.
+func publishDueEvents(ctx context.Context, cfg *Config) (int64, error) {
+	now := time.Now()
+	claimParams := database.ClaimOutboxEventsParams{
+		LeaseUntil: now.Add(time.Duration(OutboxBatchSize)*cfg.EventPublishTimeout + time.Minute),
+		Now:        now,
+		Limit:      OutboxBatchSize,
+	}
+	rows, err := cfg.DB.ClaimOutboxEvents(ctx, claimParams)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim events: %w", err)
+	}
+	slices.SortFunc(rows, func(a, b database.OutboxEvent) int { return cmp.Compare(a.ID, b.ID) })
+	for _, row := range rows {
+		if err := publishEvent(ctx, cfg, row); err != nil {
+			slog.ErrorContext(ctx, "failed to publish event", "event_id", row.EventID, "event", row.EventType, "error", err)
+		}
+	}
+	return int64(len(rows)), nil
+}
+
+// publishEvent hands an event to every sink. If any of them fails it's retried
+// later with exponential backoff, on all of them: sinks that took it already
+// get it twice.
+func publishEvent(ctx context.Context, cfg *Config, row database.OutboxEvent) error {
+	event := events.Event{
+		ID:            row.EventID,
+		Type:          row.EventType,
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		UserID:        row.UserID,
+		CreatedAt:     row.CreatedAt,
+		Data:          json.RawMessage(row.Payload),
+	}
+	var errs []error
+	for _, sink := range cfg.EventSinks {
+		if err := publishToSink(ctx, cfg, sink, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+	if publishErr := errors.Join(errs...); publishErr != nil {
+		failureParams := database.RecordOutboxEventFailureParams{
+			LastError:     publishErr.Error(),
+			NextAttemptAt: time.Now().Add(retryDelay(cfg.EventRetryDelay, MaxEventRetryDelay, row.Attempts+1)),
+			ID:            row.ID,
+		}
+		if err := cfg.DB.RecordOutboxEventFailure(ctx, failureParams); err != nil {
+			return fmt.Errorf("failed to reschedule event: %w", err)
+		}
+		return publishErr
+	}
+	publishedParams := database.MarkOutboxEventPublishedParams{
+		PublishedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:          row.ID,
+	}
+	if err := cfg.DB.MarkOutboxEventPublished(ctx, publishedParams); err != nil {
+		return fmt.Errorf("failed to mark event published: %w", err)
+	}
+	return nil
+}
+
+func publishToSink(ctx context.Context, cfg *Config, sink events.Sink, event events.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.EventPublishTimeout)
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "event.publish",
+		attribute.String("event.sink", sink.Name()),
+		attribute.String("event.id", event.ID),
+		attribute.String("event.type", event.Type),
+	)
+	err := sink.Publish(ctx, event)
+	tracing.End(span, err)
+	outcome := metrics.OutcomeSuccess
+	if err != nil {
+		outcome = metrics.OutcomeError
+	}
+	metrics.EventsPublished.WithLabelValues(sink.Name(), outcome).Inc()
+	return err
+}
+
+// retryDelay doubles the base delay after each failed attempt, up to limit.
+func retryDelay(base, limit time.Duration, attempts int64) time.Duration {
+	delay := base
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= limit {
+			return limit
+		}
+	}
+	return delay
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/charlesaraya/video-manager-go/internal/metrics"
+)
+
+const (
+	AccountPurgeInterval time.Duration = time.Hour
+	AccountPurgeQueue    string        = "account_purge"
+)
+
+// RunAccountPurger deletes accounts whose deletion grace period is over, along
+// with their personal library and its stored blobs. It blocks until ctx is
+// cancelled.
+func RunAccountPurger(ctx context.Context, cfg *Config) {
+	ticker := time.NewTicker(AccountPurgeInterval)
+	defer ticker.Stop()
+	for {
+		if err := purgeDueAccounts(ctx, cfg); err != nil {
+			slog.ErrorContext(ctx, "failed to purge accounts", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// errDeletionCancelled is what purgeAccount fails with when the account is no
+// longer due for deletion, e.g. because its owner restored it meanwhile.
+var errDeletionCancelled = errors.New("account deletion was cancelled")
+
+func purgeDueAccounts(ctx context.Context, cfg *Config) error {
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	users, err := cfg.DB.GetUsersDueForDeletion(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to get users due for deletion: %w", err)
+	}
+	metrics.JobQueueDepth.WithLabelValues(AccountPurgeQueue).Set(float64(len(users)))
+	for _, user := range users {
+		err := purgeAccount(ctx, cfg, user.ID, now, nil)
+		if errors.Is(err, errDeletionCancelled) {
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to purge account", "user_id", user.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// purgeAccount deletes the account right away, unless dueBy is set: then only
+// if its deletion is still scheduled by then, failing with
+// errDeletionCancelled otherwise. It runs record, when given, in the
+// transaction that deletes the rows, so that whoever triggered the purge gets
+// recorded along with it.
+func purgeAccount(ctx context.Context, cfg *Config, userID string, dueBy sql.NullTime, record func(q database.Querier) error) error {
+	// The personal organization shares the user's id and goes with the account,
+	// including videos other members added to it. Videos the user added to
+	// shared organizations stay there, without an uploader.
+	var videos []database.Video
+	// The rows go together with their events, or not at all.
+	err := cfg.InTx(ctx, func(q database.Querier) error {
+		user, err := q.GetUserByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if videos, err = q.GetVideosByOrganization(ctx, userID); err != nil {
+			return fmt.Errorf("failed to get organization videos: %w", err)
+		}
+		if err := q.DeleteVideosByOrganization(ctx, userID); err != nil {
+			return fmt.Errorf("failed to delete videos: %w", err)
+		}
+		for _, video := range videos {
+			if err := recordEvent(ctx, q, videoEvent(EventVideoDeleted, video)); err != nil {
+				return err
+			}
+		}
+		if err := q.DeleteMembershipsByUser(ctx, userID); err != nil {
+			return fmt.Errorf("failed to delete organization memberships: %w", err)
+		}
+		if err := q.DeleteOrganization(ctx, userID); err != nil {
+			return fmt.Errorf("failed to delete personal organization: %w", err)
+		}
+		if err := q.DeleteRefreshTokensByUser(ctx, userID); err != nil {
+			return fmt.Errorf("failed to delete refresh tokens: %w", err)
+		}
+		if dueBy.Valid {
+			rows, err := q.DeleteUserDueForDeletion(ctx, database.DeleteUserDueForDeletionParams{
+				ID:                  userID,
+				DeletionScheduledAt: dueBy,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to delete user: %w", err)
+			}
+			if rows == 0 {
+				return errDeletionCancelled
+			}
+		} else if err := q.DeleteUser(ctx, userID); err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+		if err := recordEvent(ctx, q, userEvent(EventUserDeleted, user)); err != nil {
+			return err
+		}
+		if record == nil {
+			return nil
+		}
+		return record(q)
+	})
+	if err != nil {
+		return err
+	}
+	// Blobs only go once their rows are gone for good. A failure leaves files
+	// nothing points to anymore, which beats rows pointing to missing files.
+	for _, video := range videos {
+		if err := deleteVideoBlobs(ctx, cfg, video); err != nil {
+			slog.ErrorContext(ctx, "failed to delete video blobs", "video_id", video.ID, "error", err)
+		}
+	}
+	return nil
+}
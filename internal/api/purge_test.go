@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/database"
+)
+
+func TestPurgeAccountSkipsRestoredAccounts(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	user := createTestUser(t, cfg.DB)
+	// The purger listed the account, then its owner restored it.
+	if err := cfg.DB.CancelUserDeletion(ctx, user.ID); err != nil {
+		t.Fatalf("failed to cancel deletion: %v", err)
+	}
+	if err := purgeAccount(ctx, cfg, user.ID, now, nil); !errors.Is(err, errDeletionCancelled) {
+		t.Errorf("purging a restored account: got error %v, want %v", err, errDeletionCancelled)
+	}
+	if _, err := cfg.DB.GetUserByID(ctx, user.ID); err != nil {
+		t.Errorf("failed to get restored account: %v", err)
+	}
+
+	err := cfg.DB.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{
+		DeletionScheduledAt: sql.NullTime{Time: now.Time.Add(-time.Minute), Valid: true},
+		ID:                  user.ID,
+	})
+	if err != nil {
+		t.Fatalf("failed to schedule deletion: %v", err)
+	}
+	if err := purgeAccount(ctx, cfg, user.ID, now, nil); err != nil {
+		t.Fatalf("failed to purge account: %v", err)
+	}
+	if _, err := cfg.DB.GetUserByID(ctx, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("getting a purged account: got error %v, want %v", err, sql.ErrNoRows)
+	}
+}
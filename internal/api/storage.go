@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/charlesaraya/video-manager-go/internal/metrics"
+	"github.com/charlesaraya/video-manager-go/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// thumbnailPath maps a thumbnail URL served from the assets directory back to
+// its file on disk.
+func thumbnailPath(cfg *Config, thumbnailURL string) (string, bool) {
+	fileName, ok := strings.CutPrefix(thumbnailURL, cfg.AssetsBrowserURL)
+	if !ok || fileName == "" || strings.Contains(fileName, "/") {
+		return "", false
+	}
+	return filepath.Join(cfg.AssetsDirPath, fileName), true
+}
+
+// videoObjectKey maps a video URL back to its key in blob storage.
+func videoObjectKey(cfg *Config, videoURL string) (string, bool) {
+	return cfg.Storage.Key(videoURL)
+}
+
+// observeStorage runs a blob storage operation in its own span and records
+// its duration and outcome.
+func observeStorage(ctx context.Context, operation string, call func(context.Context) error) error {
+	ctx, span := tracing.Start(ctx, "storage."+operation, attribute.String("storage.operation", operation))
+	start := time.Now()
+	err := call(ctx)
+	metrics.ObserveStorage(operation, start, err)
+	tracing.End(span, err)
+	return err
+}
+
+func openVideoObject(ctx context.Context, cfg *Config, key string) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	err := observeStorage(ctx, "get_object", func(ctx context.Context) (err error) {
+		body, err = cfg.Storage.Get(ctx, key)
+		return err
+	})
+	return body, err
+}
+
+// deleteVideoBlobs removes the stored thumbnail and video of a video row.
+func deleteVideoBlobs(ctx context.Context, cfg *Config, video database.Video) error {
+	if path, ok := thumbnailPath(cfg, video.ThumbnailUrl); ok {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove thumbnail: %w", err)
+		}
+	}
+	if key, ok := videoObjectKey(cfg, video.VideoUrl); ok {
+		err := observeStorage(ctx, "delete_object", func(ctx context.Context) error {
+			return cfg.Storage.Delete(ctx, key)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
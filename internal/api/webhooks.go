@@ -0,0 +1,357 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/charlesaraya/video-manager-go/internal/events"
+	"github.com/charlesaraya/video-manager-go/internal/metrics"
+	"github.com/charlesaraya/video-manager-go/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	WebhookStatusPending   string = "pending"
+	WebhookStatusSucceeded string = "succeeded"
+	WebhookStatusFailed    string = "failed"
+
+	WebhookIDHeader        string = "X-Webhook-ID"
+	WebhookEventHeader     string = "X-Webhook-Event"
+	WebhookTimestampHeader string = "X-Webhook-Timestamp"
+	WebhookSignatureHeader string = "X-Webhook-Signature"
+
+	WebhookDeliveryQueue string        = "webhook_delivery"
+	WebhookBatchSize     int64         = 20
+	MaxWebhookRetryDelay time.Duration = 6 * time.Hour
+	// WebhookResponseLogLimit bounds how much of an endpoint's answer is kept
+	// in the delivery log.
+	WebhookResponseLogLimit int64 = 1024
+)
+
+// WebhookEventTypes are the events webhooks receive and can filter on.
+var WebhookEventTypes = []string{
+	EventVideoCreated,
+	EventVideoUploaded,
+	EventVideoProcessed,
+	EventVideoDeleted,
+}
+
+// webhookEvent is the body of every delivery of an event.
+type webhookEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// webhookEvents splits the stored event filter of a subscription, empty means
+// every event.
+func webhookEvents(sub database.WebhookSubscription) []string {
+	if sub.Events == "" {
+		return []string{}
+	}
+	return strings.Split(sub.Events, ",")
+}
+
+func subscribedTo(sub database.WebhookSubscription, eventType string) bool {
+	return sub.Events == "" || slices.Contains(webhookEvents(sub), eventType)
+}
+
+// webhookSink is the event sink queueing a delivery of each video event to
+// every active webhook of the video's owner that wants it. Each event is only
+// queued once per webhook, however many times it's published.
+type webhookSink struct {
+	db database.Querier
+}
+
+func (s webhookSink) Name() string {
+	return "webhooks"
+}
+
+func (s webhookSink) Publish(ctx context.Context, event events.Event) error {
+	if !slices.Contains(WebhookEventTypes, event.Type) {
+		return nil
+	}
+	subs, err := s.db.GetActiveWebhookSubscriptionsByUser(ctx, event.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+	var payload []byte
+	for _, sub := range subs {
+		if !subscribedTo(sub, event.Type) {
+			continue
+		}
+		if payload == nil {
+			body := webhookEvent{ID: event.ID, Type: event.Type, CreatedAt: event.CreatedAt.UTC(), Data: event.Data}
+			if payload, err = json.Marshal(body); err != nil {
+				return fmt.Errorf("failed to marshal webhook event: %w", err)
+			}
+		}
+		deliveryParams := database.CreateWebhookDeliveryParams{
+			ID:             uuid.New().String(),
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        string(payload),
+			NextAttemptAt:  time.Now(),
+		}
+		if err := s.db.CreateWebhookDelivery(ctx, deliveryParams); err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// RunWebhookDispatcher sends the queued webhook deliveries as they become due
+// and reschedules the failed ones. It blocks until ctx is cancelled.
+func RunWebhookDispatcher(ctx context.Context, cfg *Config) {
+	ticker := time.NewTicker(cfg.WebhookPollInterval)
+	defer ticker.Stop()
+	for {
+		// Full batches mean more deliveries are probably due already.
+		for {
+			claimed, err := deliverDueWebhooks(ctx, cfg)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to deliver webhooks", "error", err)
+			}
+			if err != nil || claimed < WebhookBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+		if pending, err := cfg.DB.CountPendingWebhookDeliveries(ctx); err == nil {
+			metrics.JobQueueDepth.WithLabelValues(WebhookDeliveryQueue).Set(float64(pending))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDueWebhooks claims a batch of due deliveries and sends them
+// concurrently. Claiming pushes them back by a lease long enough to send
+// them, so they only get sent again if this process dies on the way.
+func deliverDueWebhooks(ctx context.Context, cfg *Config) (int64, error) {
+	now := time.Now()
+	claimParams := database.ClaimWebhookDeliveriesParams{
+		LeaseUntil: now.Add(cfg.WebhookClient.Timeout + time.Minute),
+		Now:        now,
+		Limit:      WebhookBatchSize,
+	}
+	deliveries, err := cfg.DB.ClaimWebhookDeliveries(ctx, claimParams)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := deliverWebhook(ctx, cfg, delivery); err != nil {
+				slog.ErrorContext(ctx, "failed to deliver webhook", "delivery_id", delivery.ID, "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+	return int64(len(deliveries)), nil
+}
+
+// webhookAttempt is the outcome of sending a delivery once.
+type webhookAttempt struct {
+	status   int
+	body     string
+	err      error
+	duration time.Duration
+}
+
+func (a webhookAttempt) succeeded() bool {
+	return a.err == nil && a.status >= 200 && a.status < 300
+}
+
+// deliverWebhook sends a delivery to its subscription and records the
+// attempt: a success or the last allowed failure ends it, other failures
+// schedule a retry with exponential backoff.
+func deliverWebhook(ctx context.Context, cfg *Config, delivery database.WebhookDelivery) error {
+	sub, err := cfg.DB.GetWebhookSubscriptionByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	var attempt webhookAttempt
+	if sub.Active {
+		attempt = sendWebhook(ctx, cfg, sub, delivery)
+	} else {
+		// Redeliver once the subscription is active again.
+		attempt.err = errors.New("webhook is disabled")
+	}
+	attemptParams := database.CreateWebhookDeliveryAttemptParams{
+		DeliveryID:     delivery.ID,
+		ResponseStatus: sql.NullInt64{Int64: int64(attempt.status), Valid: attempt.status != 0},
+		ResponseBody:   attempt.body,
+		DurationMs:     attempt.duration.Milliseconds(),
+	}
+	if attempt.err != nil {
+		attemptParams.Error = attempt.err.Error()
+	}
+	if err := cfg.DB.CreateWebhookDeliveryAttempt(ctx, attemptParams); err != nil {
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+
+	attempts := delivery.Attempts + 1
+	recordParams := database.RecordWebhookDeliveryAttemptParams{
+		Status:        WebhookStatusPending,
+		NextAttemptAt: time.Now().Add(retryDelay(cfg.WebhookRetryDelay, MaxWebhookRetryDelay, attempts)),
+		ID:            delivery.ID,
+	}
+	switch {
+	case attempt.succeeded():
+		recordParams.Status = WebhookStatusSucceeded
+		metrics.WebhookDeliveries.WithLabelValues(metrics.OutcomeSuccess).Inc()
+	case !sub.Active || attempts >= int64(cfg.WebhookMaxAttempts):
+		recordParams.Status = WebhookStatusFailed
+		metrics.WebhookDeliveries.WithLabelValues(metrics.OutcomeError).Inc()
+	default:
+		metrics.WebhookDeliveries.WithLabelValues(metrics.OutcomeError).Inc()
+	}
+	if err := cfg.DB.RecordWebhookDeliveryAttempt(ctx, recordParams); err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// sendWebhook posts the payload of a delivery, signed with the secret of its
+// subscription.
+func sendWebhook(ctx context.Context, cfg *Config, sub database.WebhookSubscription, delivery database.WebhookDelivery) webhookAttempt {
+	ctx, span := tracing.Start(ctx, "webhook.deliver",
+		attribute.String("webhook.id", sub.ID),
+		attribute.String("webhook.delivery_id", delivery.ID),
+		attribute.String("webhook.event", delivery.EventType),
+	)
+	start := time.Now()
+	attempt := postWebhook(ctx, cfg, sub, delivery)
+	attempt.duration = time.Since(start)
+	span.SetAttributes(attribute.Int("http.response.status_code", attempt.status))
+	tracing.End(span, attempt.err)
+	return attempt
+}
+
+func postWebhook(ctx context.Context, cfg *Config, sub database.WebhookSubscription, delivery database.WebhookDelivery) webhookAttempt {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Url, bytes.NewReader(body))
+	if err != nil {
+		return webhookAttempt{err: err}
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "video-manager-webhooks")
+	req.Header.Set(WebhookIDHeader, delivery.ID)
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, "sha256="+signWebhook(sub.Secret, timestamp, body))
+	res, err := cfg.WebhookClient.Do(req)
+	if err != nil {
+		return webhookAttempt{err: err}
+	}
+	defer res.Body.Close()
+	logged, _ := io.ReadAll(io.LimitReader(res.Body, WebhookResponseLogLimit))
+	// The rest is drained so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<20))
+	attempt := webhookAttempt{status: res.StatusCode, body: string(logged)}
+	if !attempt.succeeded() {
+		attempt.err = fmt.Errorf("endpoint answered %s", res.Status)
+	}
+	return attempt
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with
+// the subscription secret. Receivers recompute it to check a delivery came
+// from here, and reject old timestamps to stop replays.
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var errInternalWebhookAddress = errors.New("webhook address is not public")
+
+// newWebhookClient returns the client deliveries are sent with. Endpoints are
+// picked by users, so it refuses to connect to addresses inside the network,
+// checked on the resolved address so a DNS answer can't change it after the
+// subscription was saved, and doesn't follow redirects, which could lead
+// there too.
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if internalAddress(ip) {
+				return fmt.Errorf("%w: %s", errInternalWebhookAddress, ip)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would make the connection on our behalf, out of the dialer's sight.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// internalPrefixes are the ranges internalAddress rejects on top of those
+// netip has a method for: "this network" and carrier-grade NAT.
+var internalPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// internalAddress reports whether ip is loopback, link-local, private,
+// shared, multicast or unspecified, none of which webhooks may be sent to.
+// IPv4-mapped IPv6 addresses are checked as the IPv4 address they carry.
+func internalAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsMulticast() ||
+		ip.IsPrivate() || ip.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range internalPrefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
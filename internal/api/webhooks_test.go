@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestInternalAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"fd00::1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"::", true},
+		{"100.64.0.1", true},
+		{"100.127.255.254", true},
+		{"224.0.0.1", true},
+		{"239.255.255.250", true},
+		{"ff02::1", true},
+		{"ff0e::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"::ffff:100.64.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"100.63.255.255", false},
+		{"100.128.0.1", false},
+		{"203.0.113.10", false},
+		{"::ffff:203.0.113.10", false},
+		{"2001:db8::1", false},
+	}
+	for _, test := range tests {
+		if got := internalAddress(netip.MustParseAddr(test.addr)); got != test.want {
+			t.Errorf("internalAddress(%s) = %t, want %t", test.addr, got, test.want)
+		}
+	}
+}
+
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to get server port: %v", err)
+	}
+	client := newWebhookClient(time.Second)
+	for _, host := range []string{"127.0.0.1", "[::ffff:127.0.0.1]"} {
+		res, err := client.Get("http://" + host + ":" + port + "/hook")
+		if err == nil {
+			res.Body.Close()
+		}
+		if !errors.Is(err, errInternalWebhookAddress) {
+			t.Errorf("delivering to %s: got error %v, want %v", host, err, errInternalWebhookAddress)
+		}
+	}
+}
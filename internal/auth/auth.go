@@ -2,6 +2,8 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -35,51 +37,103 @@ func CheckPasswordHash(hash, password string) error {
 	return nil
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+func MakeJWT(userID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return makeToken(userID, keys, expiresIn)
+}
+
+func ValidateJWT(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	access, err := ParseAccessJWT(tokenString, keys)
+	return access.UserID, err
+}
+
+// AccessToken is who a valid access token was issued for. ActorID is the
+// admin acting as the user for impersonation tokens, and uuid.Nil otherwise.
+type AccessToken struct {
+	UserID  uuid.UUID
+	ActorID uuid.UUID
+}
+
+func ParseAccessJWT(tokenString string, keys *KeySet) (AccessToken, error) {
+	return validateToken(tokenString, keys)
+}
+
+type actorClaim struct {
+	Subject string `json:"sub"`
+}
+
+type impersonationClaims struct {
+	jwt.RegisteredClaims
+	Actor actorClaim `json:"act"`
+}
+
+// MakeImpersonationJWT issues an access token for userID on behalf of actorID.
+// The actor is recorded in the RFC 8693 "act" claim.
+func MakeImpersonationJWT(userID, actorID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	claims := &impersonationClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    TokenTypeAccess,
+			Subject:   userID.String(),
+		},
+		Actor: actorClaim{Subject: actorID.String()},
+	}
+	signedToken, err := keys.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signedToken, nil
+}
+
+func makeToken(userID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
 	claims := &jwt.RegisteredClaims{
 		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
 		IssuedAt:  jwt.NewNumericDate(time.Now()),
 		Issuer:    TokenTypeAccess,
 		Subject:   userID.String(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(tokenSecret))
+	signedToken, err := keys.sign(claims)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token secret: %w", err)
+		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
 	return signedToken, err
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(tokenSecret), nil
-	})
+func validateToken(tokenString string, keys *KeySet) (AccessToken, error) {
+	claims := &impersonationClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.keyFunc)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to parse with claims: %w", err)
+		return AccessToken{}, fmt.Errorf("failed to parse with claims: %w", err)
 	}
 	issuer, err := token.Claims.GetIssuer()
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to get issuer from claims: %w", err)
+		return AccessToken{}, fmt.Errorf("failed to get issuer from claims: %w", err)
 	}
 	if issuer != TokenTypeAccess {
-		return uuid.Nil, errors.New("invalid issuer")
+		return AccessToken{}, errors.New("invalid issuer")
 	}
 	expirationTime, err := token.Claims.GetExpirationTime()
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to get expiration time from claims: %w", err)
+		return AccessToken{}, fmt.Errorf("failed to get expiration time from claims: %w", err)
 	}
 	if expirationTime.Time.Before(time.Now()) {
-		return uuid.Nil, fmt.Errorf("failed to get expiration time from claims: %w", jwt.ErrTokenExpired)
+		return AccessToken{}, fmt.Errorf("failed to get expiration time from claims: %w", jwt.ErrTokenExpired)
 	}
 	userID, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to get subject from claims: %w", err)
+		return AccessToken{}, fmt.Errorf("failed to get subject from claims: %w", err)
 	}
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to parse user ID: %w", err)
+		return AccessToken{}, fmt.Errorf("failed to parse user ID: %w", err)
+	}
+	access := AccessToken{UserID: userUUID}
+	if claims.Actor.Subject != "" {
+		if access.ActorID, err = uuid.Parse(claims.Actor.Subject); err != nil {
+			return AccessToken{}, fmt.Errorf("failed to parse actor ID: %w", err)
+		}
 	}
-	return userUUID, nil
+	return access, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -97,6 +151,21 @@ func MakeRefreshToken() (string, error) {
 	return hex.EncodeToString(key), nil
 }
 
+// MakeSecureToken returns a random, URL safe token meant to be sent to the user once.
+// Only its HashToken digest should be persisted.
+func MakeSecureToken() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(key), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func GetApiKey(headers http.Header) (string, error) {
 	apiKey := headers.Get("Authorization")
 	if apiKey == "" {
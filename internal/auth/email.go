@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+const (
+	ErrInvalidEmail string = "invalid email address"
+	MaxEmailLength  int    = 254
+)
+
+// NormalizeEmail checks that email is a bare address (no display name) and
+// returns it trimmed and lower-cased, so lookups are case-insensitive.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" || len(email) > MaxEmailLength {
+		return "", errors.New(ErrInvalidEmail)
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return "", errors.New(ErrInvalidEmail)
+	}
+	at := strings.LastIndex(addr.Address, "@")
+	if at < 1 || !strings.Contains(addr.Address[at+1:], ".") {
+		return "", errors.New(ErrInvalidEmail)
+	}
+	return strings.ToLower(addr.Address), nil
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	TOTPDigits         int           = 6
+	TOTPPeriod         time.Duration = time.Second * 30
+	TOTPSkew           int64         = 1
+	TOTPSecretSize     int           = 20
+	TOTPKeySize        int           = 32
+	RecoveryCodeCount  int           = 10
+	recoveryCodeLength int           = 10
+)
+
+// sealedTOTPPrefix marks encrypted secrets, base32 never contains a colon.
+const sealedTOTPPrefix string = "v1:"
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, TOTPSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPURI builds the otpauth:// URI understood by authenticator apps.
+func TOTPURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	return "otpauth://totp/" + label + "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// ValidateTOTP checks code against secret (RFC 6238), accepting TOTPSkew
+// periods of clock drift on either side. It returns the time step the code
+// belongs to: a code must only be accepted once, so callers should reject
+// steps no later than the last one they accepted.
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return 0, false
+	}
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+	counter := now.Unix() / int64(TOTPPeriod.Seconds())
+	for offset := -TOTPSkew; offset <= TOTPSkew; offset++ {
+		expected := hotp(key, uint64(counter+offset))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter + offset, true
+		}
+	}
+	return 0, false
+}
+
+// SealTOTPSecret encrypts secret with AES-256-GCM for storage, bound to the
+// user it belongs to so it can't be copied to another account.
+func SealTOTPSecret(key []byte, userID, secret string) (string, error) {
+	aead, err := newTOTPCipher(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), []byte(userID))
+	return sealedTOTPPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenTOTPSecret decrypts a secret sealed by SealTOTPSecret.
+func OpenTOTPSecret(key []byte, userID, sealed string) (string, error) {
+	if !IsSealedTOTPSecret(sealed) {
+		return "", errors.New("totp secret is not encrypted")
+	}
+	aead, err := newTOTPCipher(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedTOTPPrefix))
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", errors.New("malformed totp secret")
+	}
+	secret, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(userID))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+	return string(secret), nil
+}
+
+// IsSealedTOTPSecret tells secrets sealed by SealTOTPSecret from the plain
+// ones stored before secrets were encrypted.
+func IsSealedTOTPSecret(secret string) bool {
+	return strings.HasPrefix(secret, sealedTOTPPrefix)
+}
+
+func newTOTPCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != TOTPKeySize {
+		return nil, fmt.Errorf("totp key must be %d bytes", TOTPKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func hotp(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for range TOTPDigits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod)
+}
+
+// GenerateRecoveryCodes returns RecoveryCodeCount single-use codes formatted
+// as xxxxx-xxxxx. Store them with HashToken(NormalizeRecoveryCode(code)).
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	for range RecoveryCodeCount {
+		raw := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(raw))[:recoveryCodeLength]
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+	}
+	return codes, nil
+}
+
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,252 @@
+// Package config loads the server settings in layers: defaults, then a YAML or
+// TOML file, then environment variables, then command line flags. Every
+// setting has a dotted key naming it in files and flags, e.g. server.port for
+// the PORT variable and the -server.port flag.
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/auth"
+	"github.com/charlesaraya/video-manager-go/internal/migrations"
+	"github.com/charlesaraya/video-manager-go/internal/sqlite"
+	"github.com/charlesaraya/video-manager-go/internal/tracing"
+)
+
+const (
+	StorageBackendS3    string = "s3"
+	StorageBackendLocal string = "local"
+	MailerBackendSMTP   string = "smtp"
+	MailerBackendLog    string = "log"
+	LogFormatJSON       string = "json"
+	LogFormatText       string = "text"
+	EventSinkWebhooks   string = "webhooks"
+	EventSinkLog        string = "log"
+	EventSinkNATS       string = "nats"
+	EventSinkRedis      string = "redis"
+)
+
+type Config struct {
+	Server     Server     `yaml:"server" toml:"server"`
+	Database   Database   `yaml:"database" toml:"database"`
+	Tokens     Tokens     `yaml:"tokens" toml:"tokens"`
+	Storage    Storage    `yaml:"storage" toml:"storage"`
+	S3         S3         `yaml:"s3" toml:"s3"`
+	Uploads    Uploads    `yaml:"uploads" toml:"uploads"`
+	Mail       Mail       `yaml:"mail" toml:"mail"`
+	OIDC       OIDC       `yaml:"oidc" toml:"oidc"`
+	RateLimits RateLimits `yaml:"rate_limits" toml:"rate_limits"`
+	Accounts   Accounts   `yaml:"accounts" toml:"accounts"`
+	Webhooks   Webhooks   `yaml:"webhooks" toml:"webhooks"`
+	Events     Events     `yaml:"events" toml:"events"`
+	Log        Log        `yaml:"log" toml:"log"`
+	Tracing    Tracing    `yaml:"tracing" toml:"tracing"`
+}
+
+type Server struct {
+	Port              string   `yaml:"port" toml:"port" env:"PORT" help:"port to listen on"`
+	Platform          string   `yaml:"platform" toml:"platform" env:"PLATFORM" help:"deployment platform, dev enables the reset endpoint"`
+	BaseURL           string   `yaml:"base_url" toml:"base_url" env:"APP_BASE_URL" help:"public URL of the app, used in emails and redirects"`
+	AppDir            string   `yaml:"app_dir" toml:"app_dir" env:"APP_DIR_PATH" help:"directory of the web app"`
+	AssetsDir         string   `yaml:"assets_dir" toml:"assets_dir" env:"ASSETS_DIR_PATH" help:"directory thumbnails are stored in"`
+	AssetsURL         string   `yaml:"assets_url" toml:"assets_url" env:"ASSETS_BROWSER_URL" help:"path the assets directory is served at"`
+	TrustProxyHeaders bool     `yaml:"trust_proxy_headers" toml:"trust_proxy_headers" env:"TRUST_PROXY_HEADERS" help:"take client IPs from X-Forwarded-For"`
+	ShutdownTimeout   Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" help:"how long in-flight requests get to finish on shutdown"`
+	ReadinessDelay    Duration `yaml:"readiness_delay" toml:"readiness_delay" env:"SHUTDOWN_READINESS_DELAY" help:"how long readiness fails before draining starts"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" help:"how long clients get to send request headers"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" help:"how long idle keep-alive connections stay open"`
+}
+
+type Database struct {
+	Driver       string   `yaml:"driver" toml:"driver" env:"DB_DRIVER" help:"sqlite or postgres"`
+	Path         string   `yaml:"path" toml:"path" env:"DB_PATH" help:"SQLite database file"`
+	URL          string   `yaml:"url" toml:"url" env:"DATABASE_URL" help:"Postgres connection URL"`
+	AutoMigrate  bool     `yaml:"auto_migrate" toml:"auto_migrate" env:"DB_AUTO_MIGRATE" help:"apply pending migrations on startup"`
+	BusyTimeout  Duration `yaml:"busy_timeout" toml:"busy_timeout" env:"DB_BUSY_TIMEOUT" help:"how long SQLite waits on locks"`
+	MaxReadConns int      `yaml:"max_read_conns" toml:"max_read_conns" env:"DB_MAX_READ_CONNS" help:"SQLite read connections"`
+	MaxConns     int      `yaml:"max_conns" toml:"max_conns" env:"DB_MAX_CONNS" help:"Postgres connections"`
+}
+
+type Tokens struct {
+	KeysDir     string `yaml:"keys_dir" toml:"keys_dir" env:"TOKEN_KEYS_DIR" help:"directory of the JWT signing keys"`
+	ActiveKeyID string `yaml:"active_key_id" toml:"active_key_id" env:"TOKEN_ACTIVE_KEY_ID" help:"id of the key new tokens are signed with"`
+	TOTPKey     string `yaml:"totp_key" toml:"totp_key" env:"TOTP_ENCRYPTION_KEY" help:"base64 encoded 32 byte key two-factor secrets are encrypted with"`
+}
+
+// DecodeTOTPKey decodes the key TOTP secrets are encrypted with.
+func (t Tokens) DecodeTOTPKey() ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(t.TOTPKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != auth.TOTPKeySize {
+		return nil, fmt.Errorf("decodes to %d bytes, expected %d", len(key), auth.TOTPKeySize)
+	}
+	return key, nil
+}
+
+type Storage struct {
+	Backend  string `yaml:"backend" toml:"backend" env:"STORAGE_BACKEND" help:"where videos are stored, s3 or local"`
+	LocalDir string `yaml:"local_dir" toml:"local_dir" env:"STORAGE_LOCAL_DIR" help:"directory videos are stored in by the local backend"`
+}
+
+type S3 struct {
+	Bucket        string   `yaml:"bucket" toml:"bucket" env:"S3_BUCKET_NAME" help:"bucket videos are stored in"`
+	Region        string   `yaml:"region" toml:"region" env:"S3_BUCKET_REGION" help:"region of the bucket"`
+	Distribution  string   `yaml:"distribution" toml:"distribution" env:"S3_CF_DISTRIBUTION" help:"URL of the CloudFront distribution serving the bucket"`
+	URLExpiration Duration `yaml:"url_expiration" toml:"url_expiration" env:"S3_URL_EXPIRATION_LIMIT" help:"lifetime of presigned URLs"`
+}
+
+type Uploads struct {
+	MaxVideoSize         Size `yaml:"max_video_size" toml:"max_video_size" env:"UPLOAD_MAX_VIDEO_SIZE" help:"largest video upload accepted"`
+	MaxThumbnailSize     Size `yaml:"max_thumbnail_size" toml:"max_thumbnail_size" env:"UPLOAD_MAX_THUMBNAIL_SIZE" help:"largest thumbnail upload accepted"`
+	RequireVerifiedEmail bool `yaml:"require_verified_email" toml:"require_verified_email" env:"REQUIRE_VERIFIED_EMAIL_FOR_UPLOADS" help:"only let users with a verified email upload"`
+}
+
+type Mail struct {
+	Backend      string `yaml:"backend" toml:"backend" env:"MAILER_BACKEND" help:"smtp, or log to write emails to a file"`
+	LogPath      string `yaml:"log_path" toml:"log_path" env:"MAILER_LOG_PATH" help:"file the log backend writes to, stdout when empty"`
+	SMTPHost     string `yaml:"smtp_host" toml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `yaml:"smtp_port" toml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" toml:"smtp_password" env:"SMTP_PASSWORD"`
+	SMTPFrom     string `yaml:"smtp_from" toml:"smtp_from" env:"SMTP_FROM"`
+}
+
+// OIDC single sign-on is off unless an issuer is set.
+type OIDC struct {
+	Issuer       string `yaml:"issuer" toml:"issuer" env:"OIDC_ISSUER"`
+	ClientID     string `yaml:"client_id" toml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+	RedirectURL  string `yaml:"redirect_url" toml:"redirect_url" env:"OIDC_REDIRECT_URL" help:"defaults to the callback under the base URL"`
+}
+
+type RateLimits struct {
+	Auth               string   `yaml:"auth" toml:"auth" env:"RATE_LIMIT_AUTH" help:"requests/window per client on auth routes"`
+	Uploads            string   `yaml:"uploads" toml:"uploads" env:"RATE_LIMIT_UPLOADS" help:"requests/window per client on upload routes"`
+	Reads              string   `yaml:"reads" toml:"reads" env:"RATE_LIMIT_READS" help:"requests/window per client on read routes"`
+	LoginMaxFailures   int      `yaml:"login_max_failures" toml:"login_max_failures" env:"LOGIN_MAX_FAILURES" help:"failed logins from an address before it is locked"`
+	AccountMaxFailures int      `yaml:"account_max_failures" toml:"account_max_failures" env:"LOGIN_ACCOUNT_MAX_FAILURES" help:"failed logins for an account, from any address, before it is locked"`
+	LoginLockout       Duration `yaml:"login_lockout" toml:"login_lockout" env:"LOGIN_LOCKOUT_DURATION" help:"how long addresses and accounts stay locked"`
+}
+
+type Accounts struct {
+	DeletionGracePeriod Duration `yaml:"deletion_grace_period" toml:"deletion_grace_period" env:"ACCOUNT_DELETION_GRACE_PERIOD" help:"how long deleted accounts can be restored"`
+}
+
+type Webhooks struct {
+	Timeout      Duration `yaml:"timeout" toml:"timeout" env:"WEBHOOK_TIMEOUT" help:"how long endpoints get to answer a delivery"`
+	MaxAttempts  int      `yaml:"max_attempts" toml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" help:"attempts before a delivery is given up"`
+	RetryDelay   Duration `yaml:"retry_delay" toml:"retry_delay" env:"WEBHOOK_RETRY_DELAY" help:"delay before the first retry, doubled after each failure"`
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL" help:"how often the queue is checked for due deliveries"`
+}
+
+// Events are published from the outbox to every sink listed, the log sink
+// stands in for the brokers locally.
+type Events struct {
+	Sinks        string   `yaml:"sinks" toml:"sinks" env:"EVENT_SINKS" help:"comma separated sinks events are published to: webhooks, log, nats, redis"`
+	LogPath      string   `yaml:"log_path" toml:"log_path" env:"EVENT_LOG_PATH" help:"file the log sink writes to, stdout when empty"`
+	NATSURL      string   `yaml:"nats_url" toml:"nats_url" env:"NATS_URL" help:"server the nats sink publishes to, nats://[user:password@]host:port"`
+	NATSSubject  string   `yaml:"nats_subject" toml:"nats_subject" env:"NATS_SUBJECT_PREFIX" help:"prefix of the subjects events are published on, followed by their type"`
+	RedisURL     string   `yaml:"redis_url" toml:"redis_url" env:"REDIS_URL" help:"server the redis sink appends to, redis://[[user]:password@]host:port[/db]"`
+	RedisStream  string   `yaml:"redis_stream" toml:"redis_stream" env:"REDIS_STREAM" help:"stream events are appended to"`
+	Timeout      Duration `yaml:"timeout" toml:"timeout" env:"EVENT_PUBLISH_TIMEOUT" help:"how long a sink gets to take an event"`
+	RetryDelay   Duration `yaml:"retry_delay" toml:"retry_delay" env:"EVENT_RETRY_DELAY" help:"delay before the first retry, doubled after each failure"`
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval" env:"EVENT_POLL_INTERVAL" help:"how often the outbox is checked for new events"`
+	Retention    Duration `yaml:"retention" toml:"retention" env:"EVENT_RETENTION" help:"how long published events stay in the outbox"`
+}
+
+// SinkNames splits the sinks list, skipping blanks.
+func (e Events) SinkNames() []string {
+	var names []string
+	for name := range strings.SplitSeq(e.Sinks, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+type Log struct {
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" help:"json or text"`
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL" help:"debug, info, warn or error"`
+}
+
+type Tracing struct {
+	Exporter    string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" help:"none, otlp or stdout"`
+	ServiceName string `yaml:"service_name" toml:"service_name" env:"OTEL_SERVICE_NAME"`
+}
+
+// Default returns the settings used for anything left unset.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Port:              "8080",
+			AppDir:            "./app",
+			AssetsDir:         "./assets",
+			AssetsURL:         "/assets/",
+			ShutdownTimeout:   Duration(time.Second * 30),
+			ReadinessDelay:    Duration(time.Second * 5),
+			ReadHeaderTimeout: Duration(time.Second * 10),
+			IdleTimeout:       Duration(time.Minute * 2),
+		},
+		Database: Database{
+			Driver:       migrations.DriverSQLite,
+			AutoMigrate:  true,
+			BusyTimeout:  Duration(sqlite.DefaultBusyTimeout),
+			MaxReadConns: sqlite.DefaultMaxReadConns,
+			MaxConns:     10,
+		},
+		Storage: Storage{
+			Backend:  StorageBackendS3,
+			LocalDir: "./data/videos",
+		},
+		S3: S3{
+			URLExpiration: Duration(time.Hour),
+		},
+		Mail: Mail{
+			Backend: MailerBackendLog,
+		},
+		Uploads: Uploads{
+			MaxVideoSize:     GiB,
+			MaxThumbnailSize: 10 * MiB,
+		},
+		RateLimits: RateLimits{
+			Auth:               "10/1m",
+			Uploads:            "30/1h",
+			Reads:              "120/1m",
+			LoginMaxFailures:   5,
+			AccountMaxFailures: 20,
+			LoginLockout:       Duration(time.Minute * 15),
+		},
+		Accounts: Accounts{
+			DeletionGracePeriod: Duration(time.Hour * 24 * 30),
+		},
+		Webhooks: Webhooks{
+			Timeout:      Duration(time.Second * 10),
+			MaxAttempts:  8,
+			RetryDelay:   Duration(time.Second * 30),
+			PollInterval: Duration(time.Second * 5),
+		},
+		Events: Events{
+			Sinks:        EventSinkWebhooks,
+			NATSSubject:  "video-manager",
+			RedisStream:  "video-manager:events",
+			Timeout:      Duration(time.Second * 10),
+			RetryDelay:   Duration(time.Second * 5),
+			PollInterval: Duration(time.Second),
+			Retention:    Duration(time.Hour * 24 * 7),
+		},
+		Log: Log{
+			Format: LogFormatJSON,
+			Level:  "info",
+		},
+		Tracing: Tracing{
+			Exporter:    tracing.ExporterNone,
+			ServiceName: "video-manager",
+		},
+	}
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// setting is a single value of the config, found by walking its structs.
+type setting struct {
+	key   string
+	env   string
+	help  string
+	value reflect.Value
+}
+
+func settings(cfg *Config) []setting {
+	var found []setting
+	var walk func(prefix string, value reflect.Value)
+	walk = func(prefix string, value reflect.Value) {
+		for i := range value.NumField() {
+			field := value.Type().Field(i)
+			key := prefix + field.Tag.Get("yaml")
+			if field.Type.Kind() == reflect.Struct {
+				walk(key+".", value.Field(i))
+				continue
+			}
+			found = append(found, setting{
+				key:   key,
+				env:   field.Tag.Get("env"),
+				help:  field.Tag.Get("help"),
+				value: value.Field(i),
+			})
+		}
+	}
+	walk("", reflect.ValueOf(cfg).Elem())
+	return found
+}
+
+func (s setting) set(raw string) error {
+	if unmarshaler, ok := s.value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(raw))
+	}
+	switch s.value.Kind() {
+	case reflect.String:
+		s.value.SetString(raw)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		s.value.SetBool(value)
+	case reflect.Int:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		s.value.SetInt(int64(value))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
+
+// flagValue records a flag so it can be applied after the file and env.
+type flagValue struct {
+	isBool bool
+	raw    *string
+}
+
+func (f flagValue) String() string {
+	if f.raw == nil {
+		return ""
+	}
+	return *f.raw
+}
+
+func (f flagValue) Set(raw string) error {
+	*f.raw = raw
+	return nil
+}
+
+func (f flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// Load reads the settings from a .env file if there is one, the config file
+// given by -config or CONFIG_FILE, the environment and then the flags in args,
+// and returns the arguments left after the flags. Settings that fail to parse
+// are reported together; call Validate to check the result.
+func Load(name string, args []string) (*Config, []string, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to load .env file: %w", err)
+	}
+	cfg := Default()
+	all := settings(cfg)
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file (CONFIG_FILE)")
+	raw := make(map[string]*string, len(all))
+	for _, s := range all {
+		// Starting from the default shows it in the usage message.
+		raw[s.key] = new(string)
+		*raw[s.key] = fmt.Sprint(s.value.Interface())
+		usage := s.help
+		if s.env != "" {
+			usage = strings.TrimSpace(usage + " (" + s.env + ")")
+		}
+		flags.Var(flagValue{isBool: s.value.Kind() == reflect.Bool, raw: raw[s.key]}, s.key, usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configPath != "" {
+		if err := loadFile(cfg, *configPath); err != nil {
+			return nil, nil, err
+		}
+	}
+	var errs []error
+	for _, s := range all {
+		if s.env == "" {
+			continue
+		}
+		if value := os.Getenv(s.env); value != "" {
+			if err := s.set(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+			}
+		}
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if value, ok := raw[f.Name]; ok {
+			s := settingByKey(all, f.Name)
+			if err := s.set(*value); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", f.Name, err))
+			}
+		}
+	})
+	return cfg, flags.Args(), errors.Join(errs...)
+}
+
+func settingByKey(all []setting, key string) setting {
+	for _, s := range all {
+		if s.key == key {
+			return s
+		}
+	}
+	panic("unknown setting " + key)
+}
+
+// loadFile decodes the file at path over cfg, keeping the values of keys it
+// doesn't set. Unknown keys are errors, they are most likely typos.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		metadata, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("failed to parse config file %s: unknown keys %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("unsupported config file %s, use .yaml, .yml or .toml", path)
+	}
+	return nil
+}
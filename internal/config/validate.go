@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/charlesaraya/video-manager-go/internal/migrations"
+	"github.com/charlesaraya/video-manager-go/internal/ratelimit"
+	"github.com/charlesaraya/video-manager-go/internal/tracing"
+)
+
+// validator collects every problem so they can be fixed in one go.
+type validator struct {
+	envs map[string]string
+	errs []error
+}
+
+func (v *validator) fail(key, format string, args ...any) {
+	name := key
+	if env := v.envs[key]; env != "" {
+		name += " (" + env + ")"
+	}
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", name, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) required(key, value string) {
+	if value == "" {
+		v.fail(key, "must be set")
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		v.fail(key, "%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+}
+
+func (v *validator) positive(key string, value int64) {
+	if value <= 0 {
+		v.fail(key, "must be positive")
+	}
+}
+
+func (v *validator) absoluteURL(key, value string) {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		v.fail(key, "%q is not an absolute URL", value)
+	}
+}
+
+func newValidator(cfg *Config) *validator {
+	envs := map[string]string{}
+	for _, s := range settings(cfg) {
+		envs[s.key] = s.env
+	}
+	return &validator{envs: envs}
+}
+
+// ValidateDatabase only checks the database settings, for commands that don't
+// start the server.
+func (cfg *Config) ValidateDatabase() error {
+	v := newValidator(cfg)
+	cfg.validateDatabase(v)
+	return errors.Join(v.errs...)
+}
+
+func (cfg *Config) validateDatabase(v *validator) {
+	v.oneOf("database.driver", cfg.Database.Driver, migrations.DriverSQLite, migrations.DriverPostgres)
+	switch cfg.Database.Driver {
+	case migrations.DriverSQLite:
+		v.required("database.path", cfg.Database.Path)
+		v.positive("database.busy_timeout", int64(cfg.Database.BusyTimeout))
+		v.positive("database.max_read_conns", int64(cfg.Database.MaxReadConns))
+	case migrations.DriverPostgres:
+		v.required("database.url", cfg.Database.URL)
+		v.positive("database.max_conns", int64(cfg.Database.MaxConns))
+	}
+}
+
+// Validate checks every setting the server needs and returns all problems
+// found, joined. Settings of subsystems that are turned off aren't required.
+func (cfg *Config) Validate() error {
+	v := newValidator(cfg)
+	cfg.validateDatabase(v)
+
+	v.required("server.port", cfg.Server.Port)
+	v.required("server.platform", cfg.Server.Platform)
+	v.required("server.base_url", cfg.Server.BaseURL)
+	if cfg.Server.BaseURL != "" {
+		v.absoluteURL("server.base_url", cfg.Server.BaseURL)
+	}
+	v.required("server.app_dir", cfg.Server.AppDir)
+	v.required("server.assets_dir", cfg.Server.AssetsDir)
+	v.required("server.assets_url", cfg.Server.AssetsURL)
+	v.positive("server.shutdown_timeout", int64(cfg.Server.ShutdownTimeout))
+	v.positive("server.read_header_timeout", int64(cfg.Server.ReadHeaderTimeout))
+	v.positive("server.idle_timeout", int64(cfg.Server.IdleTimeout))
+	// Zero is fine here, it only skips waiting for load balancers to notice.
+	if cfg.Server.ReadinessDelay < 0 {
+		v.fail("server.readiness_delay", "must not be negative")
+	}
+
+	v.required("tokens.keys_dir", cfg.Tokens.KeysDir)
+	v.required("tokens.active_key_id", cfg.Tokens.ActiveKeyID)
+	v.required("tokens.totp_key", cfg.Tokens.TOTPKey)
+	if cfg.Tokens.TOTPKey != "" {
+		if _, err := cfg.Tokens.DecodeTOTPKey(); err != nil {
+			v.fail("tokens.totp_key", "invalid key: %v", err)
+		}
+	}
+
+	v.oneOf("storage.backend", cfg.Storage.Backend, StorageBackendS3, StorageBackendLocal)
+	switch cfg.Storage.Backend {
+	case StorageBackendS3:
+		v.required("s3.bucket", cfg.S3.Bucket)
+		v.required("s3.region", cfg.S3.Region)
+		v.required("s3.distribution", cfg.S3.Distribution)
+		if cfg.S3.Distribution != "" {
+			v.absoluteURL("s3.distribution", cfg.S3.Distribution)
+		}
+		v.positive("s3.url_expiration", int64(cfg.S3.URLExpiration))
+	case StorageBackendLocal:
+		v.required("storage.local_dir", cfg.Storage.LocalDir)
+	}
+	v.positive("uploads.max_video_size", int64(cfg.Uploads.MaxVideoSize))
+	v.positive("uploads.max_thumbnail_size", int64(cfg.Uploads.MaxThumbnailSize))
+
+	v.oneOf("mail.backend", cfg.Mail.Backend, MailerBackendSMTP, MailerBackendLog)
+	if cfg.Mail.Backend == MailerBackendSMTP {
+		v.required("mail.smtp_host", cfg.Mail.SMTPHost)
+		v.required("mail.smtp_port", cfg.Mail.SMTPPort)
+		v.required("mail.smtp_from", cfg.Mail.SMTPFrom)
+	}
+
+	if cfg.OIDC.Issuer != "" {
+		v.required("oidc.client_id", cfg.OIDC.ClientID)
+	}
+
+	for key, spec := range map[string]string{
+		"rate_limits.auth":    cfg.RateLimits.Auth,
+		"rate_limits.uploads": cfg.RateLimits.Uploads,
+		"rate_limits.reads":   cfg.RateLimits.Reads,
+	} {
+		if _, err := ratelimit.ParseLimiter(spec); err != nil {
+			v.fail(key, "%v", err)
+		}
+	}
+	v.positive("rate_limits.login_max_failures", int64(cfg.RateLimits.LoginMaxFailures))
+	v.positive("rate_limits.account_max_failures", int64(cfg.RateLimits.AccountMaxFailures))
+	v.positive("rate_limits.login_lockout", int64(cfg.RateLimits.LoginLockout))
+	v.positive("accounts.deletion_grace_period", int64(cfg.Accounts.DeletionGracePeriod))
+	v.positive("webhooks.timeout", int64(cfg.Webhooks.Timeout))
+	v.positive("webhooks.max_attempts", int64(cfg.Webhooks.MaxAttempts))
+	v.positive("webhooks.retry_delay", int64(cfg.Webhooks.RetryDelay))
+	v.positive("webhooks.poll_interval", int64(cfg.Webhooks.PollInterval))
+
+	for _, sink := range cfg.Events.SinkNames() {
+		v.oneOf("events.sinks", sink, EventSinkWebhooks, EventSinkLog, EventSinkNATS, EventSinkRedis)
+		switch sink {
+		case EventSinkNATS:
+			v.required("events.nats_url", cfg.Events.NATSURL)
+			if cfg.Events.NATSURL != "" {
+				v.absoluteURL("events.nats_url", cfg.Events.NATSURL)
+			}
+			v.required("events.nats_subject", cfg.Events.NATSSubject)
+		case EventSinkRedis:
+			v.required("events.redis_url", cfg.Events.RedisURL)
+			if cfg.Events.RedisURL != "" {
+				v.absoluteURL("events.redis_url", cfg.Events.RedisURL)
+			}
+			v.required("events.redis_stream", cfg.Events.RedisStream)
+		}
+	}
+	v.positive("events.timeout", int64(cfg.Events.Timeout))
+	v.positive("events.retry_delay", int64(cfg.Events.RetryDelay))
+	v.positive("events.poll_interval", int64(cfg.Events.PollInterval))
+	v.positive("events.retention", int64(cfg.Events.Retention))
+
+	v.oneOf("log.format", strings.ToLower(cfg.Log.Format), LogFormatJSON, LogFormatText)
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Log.Level)); err != nil {
+		v.fail("log.level", "%q is not one of debug, info, warn, error", cfg.Log.Level)
+	}
+	v.oneOf("tracing.exporter", cfg.Tracing.Exporter, tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout)
+
+	slices.SortFunc(v.errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errors.Join(v.errs...)
+}
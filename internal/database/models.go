@@ -9,6 +9,98 @@ import (
 	"time"
 )
 
+type AuditEvent struct {
+	ID             int64          `json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	Action         string         `json:"action"`
+	ActorID        sql.NullString `json:"actor_id"`
+	Ip             string         `json:"ip"`
+	TargetType     string         `json:"target_type"`
+	TargetID       string         `json:"target_id"`
+	Diff           string         `json:"diff"`
+	ImpersonatorID sql.NullString `json:"impersonator_id"`
+}
+
+type EmailVerificationToken struct {
+	TokenHash string       `json:"token_hash"`
+	UserID    string       `json:"user_id"`
+	Email     string       `json:"email"`
+	CreatedAt time.Time    `json:"created_at"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type MfaChallenge struct {
+	ChallengeHash string    `json:"challenge_hash"`
+	UserID        string    `json:"user_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type MfaRecoveryCode struct {
+	CodeHash  string       `json:"code_hash"`
+	UserID    string       `json:"user_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type OidcLoginState struct {
+	StateHash    string    `json:"state_hash"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type Organization struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+}
+
+type OrganizationInvitation struct {
+	TokenHash      string       `json:"token_hash"`
+	OrganizationID string       `json:"organization_id"`
+	Email          string       `json:"email"`
+	Role           string       `json:"role"`
+	InvitedBy      string       `json:"invited_by"`
+	CreatedAt      time.Time    `json:"created_at"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	AcceptedAt     sql.NullTime `json:"accepted_at"`
+}
+
+type OrganizationMember struct {
+	OrganizationID string    `json:"organization_id"`
+	UserID         string    `json:"user_id"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type OutboxEvent struct {
+	ID            int64        `json:"id"`
+	EventID       string       `json:"event_id"`
+	CreatedAt     time.Time    `json:"created_at"`
+	EventType     string       `json:"event_type"`
+	AggregateType string       `json:"aggregate_type"`
+	AggregateID   string       `json:"aggregate_id"`
+	UserID        string       `json:"user_id"`
+	Payload       string       `json:"payload"`
+	Attempts      int64        `json:"attempts"`
+	NextAttemptAt time.Time    `json:"next_attempt_at"`
+	LastError     string       `json:"last_error"`
+	PublishedAt   sql.NullTime `json:"published_at"`
+}
+
+type PasswordResetToken struct {
+	TokenHash string       `json:"token_hash"`
+	UserID    string       `json:"user_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
 type RefreshToken struct {
 	Token     string       `json:"token"`
 	UserID    string       `json:"user_id"`
@@ -19,20 +111,72 @@ type RefreshToken struct {
 }
 
 type User struct {
-	ID        string    `json:"id"`
+	ID                  string         `json:"id"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	Email               string         `json:"email"`
+	Password            string         `json:"password"`
+	EmailVerifiedAt     sql.NullTime   `json:"email_verified_at"`
+	TotpSecret          sql.NullString `json:"-"`
+	TotpEnabledAt       sql.NullTime   `json:"totp_enabled_at"`
+	DeletionScheduledAt sql.NullTime   `json:"deletion_scheduled_at"`
+	Role                string         `json:"role"`
+	DisabledAt          sql.NullTime   `json:"disabled_at"`
+	TotpLastStep        int64          `json:"totp_last_step"`
+}
+
+type UserIdentity struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"`
 }
 
 type Video struct {
-	ID           string    `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	ThumbnailUrl string    `json:"thumbnail_url"`
-	VideoUrl     string    `json:"video_url"`
-	Title        string    `json:"title"`
-	Description  string    `json:"description"`
-	UserID       string    `json:"user_id"`
+	ID             string    `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	ThumbnailUrl   string    `json:"thumbnail_url"`
+	VideoUrl       string    `json:"video_url"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description"`
+	UserID         *string   `json:"user_id"`
+	OrganizationID string    `json:"organization_id"`
+}
+
+type WebhookDelivery struct {
+	ID             string       `json:"id"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	SubscriptionID string       `json:"subscription_id"`
+	EventID        string       `json:"event_id"`
+	EventType      string       `json:"event_type"`
+	Payload        string       `json:"payload"`
+	Status         string       `json:"status"`
+	Attempts       int64        `json:"attempts"`
+	NextAttemptAt  time.Time    `json:"next_attempt_at"`
+	LastAttemptAt  sql.NullTime `json:"last_attempt_at"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID             int64         `json:"id"`
+	DeliveryID     string        `json:"delivery_id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	ResponseStatus sql.NullInt64 `json:"response_status"`
+	ResponseBody   string        `json:"response_body"`
+	Error          string        `json:"error"`
+	DurationMs     int64         `json:"duration_ms"`
+}
+
+type WebhookSubscription struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    string    `json:"user_id"`
+	Url       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    string    `json:"events"`
+	Active    bool      `json:"active"`
 }
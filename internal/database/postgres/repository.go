@@ -0,0 +1,522 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/database"
+)
+
+// Repository serves database.Querier from the Postgres queries. Both packages
+// are generated from the same queries, so their models and params only differ
+// in the package they live in and convert into each other.
+type Repository struct {
+	q *Queries
+}
+
+var _ database.Querier = (*Repository)(nil)
+
+func NewRepository(db DBTX) *Repository {
+	return &Repository{q: New(db)}
+}
+
+func convertAll[From, To any](items []From, convert func(From) To) []To {
+	if items == nil {
+		return nil
+	}
+	converted := make([]To, len(items))
+	for i, item := range items {
+		converted[i] = convert(item)
+	}
+	return converted
+}
+
+func (r *Repository) AcceptOrganizationInvitation(ctx context.Context, tokenHash string) (int64, error) {
+	return r.q.AcceptOrganizationInvitation(ctx, tokenHash)
+}
+
+func (r *Repository) AddOrganizationMember(ctx context.Context, arg database.AddOrganizationMemberParams) error {
+	return r.q.AddOrganizationMember(ctx, AddOrganizationMemberParams(arg))
+}
+
+func (r *Repository) CancelUserDeletion(ctx context.Context, id string) error {
+	return r.q.CancelUserDeletion(ctx, id)
+}
+
+func (r *Repository) ClaimOutboxEvents(ctx context.Context, arg database.ClaimOutboxEventsParams) ([]database.OutboxEvent, error) {
+	items, err := r.q.ClaimOutboxEvents(ctx, ClaimOutboxEventsParams(arg))
+	return convertAll(items, func(item OutboxEvent) database.OutboxEvent {
+		return database.OutboxEvent(item)
+	}), err
+}
+
+func (r *Repository) ClaimWebhookDeliveries(ctx context.Context, arg database.ClaimWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	items, err := r.q.ClaimWebhookDeliveries(ctx, ClaimWebhookDeliveriesParams(arg))
+	return convertAll(items, func(item WebhookDelivery) database.WebhookDelivery {
+		return database.WebhookDelivery(item)
+	}), err
+}
+
+func (r *Repository) ConsumeMFAChallenge(ctx context.Context, arg database.ConsumeMFAChallengeParams) (database.MfaChallenge, error) {
+	item, err := r.q.ConsumeMFAChallenge(ctx, ConsumeMFAChallengeParams(arg))
+	return database.MfaChallenge(item), err
+}
+
+func (r *Repository) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (database.OidcLoginState, error) {
+	item, err := r.q.ConsumeOIDCLoginState(ctx, stateHash)
+	return database.OidcLoginState(item), err
+}
+
+func (r *Repository) CountOrganizationOwners(ctx context.Context, organizationID string) (int64, error) {
+	return r.q.CountOrganizationOwners(ctx, organizationID)
+}
+
+func (r *Repository) CountPendingOutboxEvents(ctx context.Context) (int64, error) {
+	return r.q.CountPendingOutboxEvents(ctx)
+}
+
+func (r *Repository) CountPendingWebhookDeliveries(ctx context.Context) (int64, error) {
+	return r.q.CountPendingWebhookDeliveries(ctx)
+}
+
+func (r *Repository) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error {
+	return r.q.CreateAuditEvent(ctx, CreateAuditEventParams(arg))
+}
+
+func (r *Repository) CreateEmailVerificationToken(ctx context.Context, arg database.CreateEmailVerificationTokenParams) (database.EmailVerificationToken, error) {
+	item, err := r.q.CreateEmailVerificationToken(ctx, CreateEmailVerificationTokenParams(arg))
+	return database.EmailVerificationToken(item), err
+}
+
+func (r *Repository) CreateMFAChallenge(ctx context.Context, arg database.CreateMFAChallengeParams) error {
+	return r.q.CreateMFAChallenge(ctx, CreateMFAChallengeParams(arg))
+}
+
+func (r *Repository) CreateOIDCLoginState(ctx context.Context, arg database.CreateOIDCLoginStateParams) error {
+	return r.q.CreateOIDCLoginState(ctx, CreateOIDCLoginStateParams(arg))
+}
+
+func (r *Repository) CreateOrganization(ctx context.Context, arg database.CreateOrganizationParams) (database.Organization, error) {
+	item, err := r.q.CreateOrganization(ctx, CreateOrganizationParams(arg))
+	return database.Organization(item), err
+}
+
+func (r *Repository) CreateOrganizationInvitation(ctx context.Context, arg database.CreateOrganizationInvitationParams) error {
+	return r.q.CreateOrganizationInvitation(ctx, CreateOrganizationInvitationParams(arg))
+}
+
+func (r *Repository) CreateOutboxEvent(ctx context.Context, arg database.CreateOutboxEventParams) error {
+	return r.q.CreateOutboxEvent(ctx, CreateOutboxEventParams(arg))
+}
+
+func (r *Repository) CreatePasswordResetToken(ctx context.Context, arg database.CreatePasswordResetTokenParams) (database.PasswordResetToken, error) {
+	item, err := r.q.CreatePasswordResetToken(ctx, CreatePasswordResetTokenParams(arg))
+	return database.PasswordResetToken(item), err
+}
+
+func (r *Repository) CreateRecoveryCode(ctx context.Context, arg database.CreateRecoveryCodeParams) error {
+	return r.q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams(arg))
+}
+
+func (r *Repository) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	item, err := r.q.CreateRefreshToken(ctx, CreateRefreshTokenParams(arg))
+	return database.RefreshToken(item), err
+}
+
+func (r *Repository) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	item, err := r.q.CreateUser(ctx, CreateUserParams(arg))
+	return database.User(item), err
+}
+
+func (r *Repository) CreateUserIdentity(ctx context.Context, arg database.CreateUserIdentityParams) (database.UserIdentity, error) {
+	item, err := r.q.CreateUserIdentity(ctx, CreateUserIdentityParams(arg))
+	return database.UserIdentity(item), err
+}
+
+func (r *Repository) CreateVerifiedUser(ctx context.Context, arg database.CreateVerifiedUserParams) (database.User, error) {
+	item, err := r.q.CreateVerifiedUser(ctx, CreateVerifiedUserParams(arg))
+	return database.User(item), err
+}
+
+func (r *Repository) CreateVideo(ctx context.Context, arg database.CreateVideoParams) (database.Video, error) {
+	item, err := r.q.CreateVideo(ctx, CreateVideoParams(arg))
+	return database.Video(item), err
+}
+
+func (r *Repository) CreateWebhookDelivery(ctx context.Context, arg database.CreateWebhookDeliveryParams) error {
+	return r.q.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams(arg))
+}
+
+func (r *Repository) CreateWebhookDeliveryAttempt(ctx context.Context, arg database.CreateWebhookDeliveryAttemptParams) error {
+	return r.q.CreateWebhookDeliveryAttempt(ctx, CreateWebhookDeliveryAttemptParams(arg))
+}
+
+func (r *Repository) CreateWebhookSubscription(ctx context.Context, arg database.CreateWebhookSubscriptionParams) (database.WebhookSubscription, error) {
+	item, err := r.q.CreateWebhookSubscription(ctx, CreateWebhookSubscriptionParams(arg))
+	return database.WebhookSubscription(item), err
+}
+
+func (r *Repository) DeleteAllEmailVerificationTokens(ctx context.Context) error {
+	return r.q.DeleteAllEmailVerificationTokens(ctx)
+}
+
+func (r *Repository) DeleteAllMFAChallenges(ctx context.Context) error {
+	return r.q.DeleteAllMFAChallenges(ctx)
+}
+
+func (r *Repository) DeleteAllOIDCLoginStates(ctx context.Context) error {
+	return r.q.DeleteAllOIDCLoginStates(ctx)
+}
+
+func (r *Repository) DeleteAllOrganizationInvitations(ctx context.Context) error {
+	return r.q.DeleteAllOrganizationInvitations(ctx)
+}
+
+func (r *Repository) DeleteAllOrganizationMembers(ctx context.Context) error {
+	return r.q.DeleteAllOrganizationMembers(ctx)
+}
+
+func (r *Repository) DeleteAllOrganizations(ctx context.Context) error {
+	return r.q.DeleteAllOrganizations(ctx)
+}
+
+func (r *Repository) DeleteAllOutboxEvents(ctx context.Context) error {
+	return r.q.DeleteAllOutboxEvents(ctx)
+}
+
+func (r *Repository) DeleteAllPasswordResetTokens(ctx context.Context) error {
+	return r.q.DeleteAllPasswordResetTokens(ctx)
+}
+
+func (r *Repository) DeleteAllRecoveryCodes(ctx context.Context) error {
+	return r.q.DeleteAllRecoveryCodes(ctx)
+}
+
+func (r *Repository) DeleteAllRefreshTokens(ctx context.Context) error {
+	return r.q.DeleteAllRefreshTokens(ctx)
+}
+
+func (r *Repository) DeleteAllUserIdentities(ctx context.Context) error {
+	return r.q.DeleteAllUserIdentities(ctx)
+}
+
+func (r *Repository) DeleteAllUsers(ctx context.Context) error {
+	return r.q.DeleteAllUsers(ctx)
+}
+
+func (r *Repository) DeleteAllVideos(ctx context.Context) error {
+	return r.q.DeleteAllVideos(ctx)
+}
+
+func (r *Repository) DeleteAllWebhookSubscriptions(ctx context.Context) error {
+	return r.q.DeleteAllWebhookSubscriptions(ctx)
+}
+
+func (r *Repository) DeleteExpiredMFAChallenges(ctx context.Context, expiresAt time.Time) error {
+	return r.q.DeleteExpiredMFAChallenges(ctx, expiresAt)
+}
+
+func (r *Repository) DeleteExpiredOIDCLoginStates(ctx context.Context, expiresAt time.Time) error {
+	return r.q.DeleteExpiredOIDCLoginStates(ctx, expiresAt)
+}
+
+func (r *Repository) DeleteMembershipsByUser(ctx context.Context, userID string) error {
+	return r.q.DeleteMembershipsByUser(ctx, userID)
+}
+
+func (r *Repository) DeleteOrganization(ctx context.Context, id string) error {
+	return r.q.DeleteOrganization(ctx, id)
+}
+
+func (r *Repository) DeletePublishedOutboxEvents(ctx context.Context, publishedAt sql.NullTime) (int64, error) {
+	return r.q.DeletePublishedOutboxEvents(ctx, publishedAt)
+}
+
+func (r *Repository) DeleteRecoveryCodesByUser(ctx context.Context, userID string) error {
+	return r.q.DeleteRecoveryCodesByUser(ctx, userID)
+}
+
+func (r *Repository) DeleteRefreshToken(ctx context.Context, token string) error {
+	return r.q.DeleteRefreshToken(ctx, token)
+}
+
+func (r *Repository) DeleteRefreshTokensByUser(ctx context.Context, userID string) error {
+	return r.q.DeleteRefreshTokensByUser(ctx, userID)
+}
+
+func (r *Repository) DeleteUser(ctx context.Context, id string) error {
+	return r.q.DeleteUser(ctx, id)
+}
+
+func (r *Repository) DeleteUserDueForDeletion(ctx context.Context, arg database.DeleteUserDueForDeletionParams) (int64, error) {
+	return r.q.DeleteUserDueForDeletion(ctx, DeleteUserDueForDeletionParams(arg))
+}
+
+func (r *Repository) DeleteVideo(ctx context.Context, id string) error {
+	return r.q.DeleteVideo(ctx, id)
+}
+
+func (r *Repository) DeleteVideosByOrganization(ctx context.Context, organizationID string) error {
+	return r.q.DeleteVideosByOrganization(ctx, organizationID)
+}
+
+func (r *Repository) DeleteWebhookSubscription(ctx context.Context, arg database.DeleteWebhookSubscriptionParams) (int64, error) {
+	return r.q.DeleteWebhookSubscription(ctx, DeleteWebhookSubscriptionParams(arg))
+}
+
+func (r *Repository) DisableUser(ctx context.Context, id string) error {
+	return r.q.DisableUser(ctx, id)
+}
+
+func (r *Repository) DisableUserTOTP(ctx context.Context, id string) error {
+	return r.q.DisableUserTOTP(ctx, id)
+}
+
+func (r *Repository) EnableUser(ctx context.Context, id string) error {
+	return r.q.EnableUser(ctx, id)
+}
+
+func (r *Repository) EnableUserTOTP(ctx context.Context, id string) error {
+	return r.q.EnableUserTOTP(ctx, id)
+}
+
+func (r *Repository) GetActiveWebhookSubscriptionsByUser(ctx context.Context, userID string) ([]database.WebhookSubscription, error) {
+	items, err := r.q.GetActiveWebhookSubscriptionsByUser(ctx, userID)
+	return convertAll(items, func(item WebhookSubscription) database.WebhookSubscription {
+		return database.WebhookSubscription(item)
+	}), err
+}
+
+func (r *Repository) GetEmailVerificationToken(ctx context.Context, tokenHash string) (database.EmailVerificationToken, error) {
+	item, err := r.q.GetEmailVerificationToken(ctx, tokenHash)
+	return database.EmailVerificationToken(item), err
+}
+
+func (r *Repository) GetOrganization(ctx context.Context, id string) (database.Organization, error) {
+	item, err := r.q.GetOrganization(ctx, id)
+	return database.Organization(item), err
+}
+
+func (r *Repository) GetOrganizationInvitation(ctx context.Context, tokenHash string) (database.OrganizationInvitation, error) {
+	item, err := r.q.GetOrganizationInvitation(ctx, tokenHash)
+	return database.OrganizationInvitation(item), err
+}
+
+func (r *Repository) GetOrganizationMember(ctx context.Context, arg database.GetOrganizationMemberParams) (database.OrganizationMember, error) {
+	item, err := r.q.GetOrganizationMember(ctx, GetOrganizationMemberParams(arg))
+	return database.OrganizationMember(item), err
+}
+
+func (r *Repository) GetOrganizationMembers(ctx context.Context, organizationID string) ([]database.GetOrganizationMembersRow, error) {
+	items, err := r.q.GetOrganizationMembers(ctx, organizationID)
+	return convertAll(items, func(item GetOrganizationMembersRow) database.GetOrganizationMembersRow {
+		return database.GetOrganizationMembersRow(item)
+	}), err
+}
+
+func (r *Repository) GetOrganizationsByMember(ctx context.Context, userID string) ([]database.GetOrganizationsByMemberRow, error) {
+	items, err := r.q.GetOrganizationsByMember(ctx, userID)
+	return convertAll(items, func(item GetOrganizationsByMemberRow) database.GetOrganizationsByMemberRow {
+		return database.GetOrganizationsByMemberRow(item)
+	}), err
+}
+
+func (r *Repository) GetPasswordResetToken(ctx context.Context, tokenHash string) (database.PasswordResetToken, error) {
+	item, err := r.q.GetPasswordResetToken(ctx, tokenHash)
+	return database.PasswordResetToken(item), err
+}
+
+func (r *Repository) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	item, err := r.q.GetRefreshToken(ctx, token)
+	return database.RefreshToken(item), err
+}
+
+func (r *Repository) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	item, err := r.q.GetUserByEmail(ctx, email)
+	return database.User(item), err
+}
+
+func (r *Repository) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	item, err := r.q.GetUserByID(ctx, id)
+	return database.User(item), err
+}
+
+func (r *Repository) GetUserIdentity(ctx context.Context, arg database.GetUserIdentityParams) (database.UserIdentity, error) {
+	item, err := r.q.GetUserIdentity(ctx, GetUserIdentityParams(arg))
+	return database.UserIdentity(item), err
+}
+
+func (r *Repository) GetUsersDueForDeletion(ctx context.Context, deletionScheduledAt sql.NullTime) ([]database.User, error) {
+	items, err := r.q.GetUsersDueForDeletion(ctx, deletionScheduledAt)
+	return convertAll(items, func(item User) database.User { return database.User(item) }), err
+}
+
+func (r *Repository) GetUsersWithTOTPSecret(ctx context.Context) ([]database.User, error) {
+	items, err := r.q.GetUsersWithTOTPSecret(ctx)
+	return convertAll(items, func(item User) database.User { return database.User(item) }), err
+}
+
+func (r *Repository) GetVideo(ctx context.Context, id string) (database.Video, error) {
+	item, err := r.q.GetVideo(ctx, id)
+	return database.Video(item), err
+}
+
+func (r *Repository) GetVideosByMember(ctx context.Context, userID string) ([]database.Video, error) {
+	items, err := r.q.GetVideosByMember(ctx, userID)
+	return convertAll(items, func(item Video) database.Video { return database.Video(item) }), err
+}
+
+func (r *Repository) GetVideosByOrganization(ctx context.Context, organizationID string) ([]database.Video, error) {
+	items, err := r.q.GetVideosByOrganization(ctx, organizationID)
+	return convertAll(items, func(item Video) database.Video { return database.Video(item) }), err
+}
+
+func (r *Repository) GetVideosByUser(ctx context.Context, userID *string) ([]database.Video, error) {
+	items, err := r.q.GetVideosByUser(ctx, userID)
+	return convertAll(items, func(item Video) database.Video { return database.Video(item) }), err
+}
+
+func (r *Repository) GetWebhookDelivery(ctx context.Context, arg database.GetWebhookDeliveryParams) (database.WebhookDelivery, error) {
+	item, err := r.q.GetWebhookDelivery(ctx, GetWebhookDeliveryParams(arg))
+	return database.WebhookDelivery(item), err
+}
+
+func (r *Repository) GetWebhookDeliveryAttempts(ctx context.Context, deliveryID string) ([]database.WebhookDeliveryAttempt, error) {
+	items, err := r.q.GetWebhookDeliveryAttempts(ctx, deliveryID)
+	return convertAll(items, func(item WebhookDeliveryAttempt) database.WebhookDeliveryAttempt {
+		return database.WebhookDeliveryAttempt(item)
+	}), err
+}
+
+func (r *Repository) GetWebhookSubscription(ctx context.Context, arg database.GetWebhookSubscriptionParams) (database.WebhookSubscription, error) {
+	item, err := r.q.GetWebhookSubscription(ctx, GetWebhookSubscriptionParams(arg))
+	return database.WebhookSubscription(item), err
+}
+
+func (r *Repository) GetWebhookSubscriptionByID(ctx context.Context, id string) (database.WebhookSubscription, error) {
+	item, err := r.q.GetWebhookSubscriptionByID(ctx, id)
+	return database.WebhookSubscription(item), err
+}
+
+func (r *Repository) GetWebhookSubscriptionsByUser(ctx context.Context, userID string) ([]database.WebhookSubscription, error) {
+	items, err := r.q.GetWebhookSubscriptionsByUser(ctx, userID)
+	return convertAll(items, func(item WebhookSubscription) database.WebhookSubscription {
+		return database.WebhookSubscription(item)
+	}), err
+}
+
+func (r *Repository) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	items, err := r.q.ListAuditEvents(ctx, ListAuditEventsParams(arg))
+	return convertAll(items, func(item AuditEvent) database.AuditEvent { return database.AuditEvent(item) }), err
+}
+
+func (r *Repository) ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.User, error) {
+	items, err := r.q.ListUsers(ctx, ListUsersParams(arg))
+	return convertAll(items, func(item User) database.User { return database.User(item) }), err
+}
+
+func (r *Repository) ListVideos(ctx context.Context, arg database.ListVideosParams) ([]database.Video, error) {
+	items, err := r.q.ListVideos(ctx, ListVideosParams(arg))
+	return convertAll(items, func(item Video) database.Video { return database.Video(item) }), err
+}
+
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, arg database.ListWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	items, err := r.q.ListWebhookDeliveries(ctx, ListWebhookDeliveriesParams(arg))
+	return convertAll(items, func(item WebhookDelivery) database.WebhookDelivery {
+		return database.WebhookDelivery(item)
+	}), err
+}
+
+func (r *Repository) MarkOutboxEventPublished(ctx context.Context, arg database.MarkOutboxEventPublishedParams) error {
+	return r.q.MarkOutboxEventPublished(ctx, MarkOutboxEventPublishedParams(arg))
+}
+
+func (r *Repository) MarkUserEmailVerified(ctx context.Context, arg database.MarkUserEmailVerifiedParams) (int64, error) {
+	return r.q.MarkUserEmailVerified(ctx, MarkUserEmailVerifiedParams(arg))
+}
+
+func (r *Repository) RecordOutboxEventFailure(ctx context.Context, arg database.RecordOutboxEventFailureParams) error {
+	return r.q.RecordOutboxEventFailure(ctx, RecordOutboxEventFailureParams(arg))
+}
+
+func (r *Repository) RecordWebhookDeliveryAttempt(ctx context.Context, arg database.RecordWebhookDeliveryAttemptParams) error {
+	return r.q.RecordWebhookDeliveryAttempt(ctx, RecordWebhookDeliveryAttemptParams(arg))
+}
+
+func (r *Repository) RedeliverWebhookDelivery(ctx context.Context, arg database.RedeliverWebhookDeliveryParams) (database.WebhookDelivery, error) {
+	item, err := r.q.RedeliverWebhookDelivery(ctx, RedeliverWebhookDeliveryParams(arg))
+	return database.WebhookDelivery(item), err
+}
+
+func (r *Repository) RemoveOrganizationMember(ctx context.Context, arg database.RemoveOrganizationMemberParams) error {
+	return r.q.RemoveOrganizationMember(ctx, RemoveOrganizationMemberParams(arg))
+}
+
+func (r *Repository) ReplaceUserTOTPSecret(ctx context.Context, arg database.ReplaceUserTOTPSecretParams) (int64, error) {
+	return r.q.ReplaceUserTOTPSecret(ctx, ReplaceUserTOTPSecretParams(arg))
+}
+
+func (r *Repository) RevokeAllRefreshTokensByUser(ctx context.Context, userID string) error {
+	return r.q.RevokeAllRefreshTokensByUser(ctx, userID)
+}
+
+func (r *Repository) RevokeRefreshToken(ctx context.Context, token string) (int64, error) {
+	return r.q.RevokeRefreshToken(ctx, token)
+}
+
+func (r *Repository) ScheduleUserDeletion(ctx context.Context, arg database.ScheduleUserDeletionParams) error {
+	return r.q.ScheduleUserDeletion(ctx, ScheduleUserDeletionParams(arg))
+}
+
+func (r *Repository) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) error {
+	return r.q.SetUserRole(ctx, SetUserRoleParams(arg))
+}
+
+func (r *Repository) SetUserTOTPSecret(ctx context.Context, arg database.SetUserTOTPSecretParams) error {
+	return r.q.SetUserTOTPSecret(ctx, SetUserTOTPSecretParams(arg))
+}
+
+func (r *Repository) UpdateOrganizationMemberRole(ctx context.Context, arg database.UpdateOrganizationMemberRoleParams) error {
+	return r.q.UpdateOrganizationMemberRole(ctx, UpdateOrganizationMemberRoleParams(arg))
+}
+
+func (r *Repository) UpdateUserEmail(ctx context.Context, arg database.UpdateUserEmailParams) (database.User, error) {
+	item, err := r.q.UpdateUserEmail(ctx, UpdateUserEmailParams(arg))
+	return database.User(item), err
+}
+
+func (r *Repository) UpdateUserPassword(ctx context.Context, arg database.UpdateUserPasswordParams) error {
+	return r.q.UpdateUserPassword(ctx, UpdateUserPasswordParams(arg))
+}
+
+func (r *Repository) UpdateVideoThumbnail(ctx context.Context, arg database.UpdateVideoThumbnailParams) (database.Video, error) {
+	item, err := r.q.UpdateVideoThumbnail(ctx, UpdateVideoThumbnailParams(arg))
+	return database.Video(item), err
+}
+
+func (r *Repository) UpdateVideoUrl(ctx context.Context, arg database.UpdateVideoUrlParams) (database.Video, error) {
+	item, err := r.q.UpdateVideoUrl(ctx, UpdateVideoUrlParams(arg))
+	return database.Video(item), err
+}
+
+func (r *Repository) UpdateWebhookSubscription(ctx context.Context, arg database.UpdateWebhookSubscriptionParams) (database.WebhookSubscription, error) {
+	item, err := r.q.UpdateWebhookSubscription(ctx, UpdateWebhookSubscriptionParams(arg))
+	return database.WebhookSubscription(item), err
+}
+
+func (r *Repository) UseEmailVerificationToken(ctx context.Context, tokenHash string) (int64, error) {
+	return r.q.UseEmailVerificationToken(ctx, tokenHash)
+}
+
+func (r *Repository) UsePasswordResetToken(ctx context.Context, tokenHash string) (int64, error) {
+	return r.q.UsePasswordResetToken(ctx, tokenHash)
+}
+
+func (r *Repository) UseRecoveryCode(ctx context.Context, arg database.UseRecoveryCodeParams) (int64, error) {
+	return r.q.UseRecoveryCode(ctx, UseRecoveryCodeParams(arg))
+}
+
+func (r *Repository) UseTOTPStep(ctx context.Context, arg database.UseTOTPStepParams) (int64, error) {
+	return r.q.UseTOTPStep(ctx, UseTOTPStepParams(arg))
+}
@@ -62,6 +62,16 @@ func (q *Queries) DeleteRefreshToken(ctx context.Context, token string) error {
 	return err
 }
 
+const deleteRefreshTokensByUser = `-- name: DeleteRefreshTokensByUser :exec
+DELETE FROM refresh_tokens
+WHERE user_id = ?
+`
+
+func (q *Queries) DeleteRefreshTokensByUser(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deleteRefreshTokensByUser, userID)
+	return err
+}
+
 const getRefreshToken = `-- name: GetRefreshToken :one
 SELECT token, user_id, created_at, updated_at, expires_at, revoked_at FROM refresh_tokens
 WHERE token = ?
@@ -81,13 +91,27 @@ func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshTok
 	return i, err
 }
 
-const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
+const revokeAllRefreshTokensByUser = `-- name: RevokeAllRefreshTokensByUser :exec
 UPDATE refresh_tokens
 SET revoked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
-WHERE token = ?
+WHERE user_id = ? AND revoked_at IS NULL
 `
 
-func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
+func (q *Queries) RevokeAllRefreshTokensByUser(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, revokeAllRefreshTokensByUser, userID)
 	return err
 }
+
+const revokeRefreshToken = `-- name: RevokeRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE token = ? AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
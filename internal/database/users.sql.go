@@ -7,8 +7,20 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
+const cancelUserDeletion = `-- name: CancelUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, cancelUserDeletion, id)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, email, password)
 VALUES (
@@ -18,7 +30,7 @@ VALUES (
     ?,
     ?
 )
-RETURNING id, created_at, updated_at, email, password
+RETURNING id, created_at, updated_at, email, password, email_verified_at, totp_secret, totp_enabled_at, deletion_scheduled_at, role, disabled_at, totp_last_step
 `
 
 type CreateUserParams struct {
@@ -36,6 +48,52 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.Email,
 		&i.Password,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const createVerifiedUser = `-- name: CreateVerifiedUser :one
+INSERT INTO users (id, created_at, updated_at, email, password, email_verified_at)
+VALUES (
+    ?,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    ?,
+    ?,
+    CURRENT_TIMESTAMP
+)
+RETURNING id, created_at, updated_at, email, password, email_verified_at, totp_secret, totp_enabled_at, deletion_scheduled_at, role, disabled_at, totp_last_step
+`
+
+type CreateVerifiedUserParams struct {
+	ID       string `json:"id"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (q *Queries) CreateVerifiedUser(ctx context.Context, arg CreateVerifiedUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createVerifiedUser, arg.ID, arg.Email, arg.Password)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.Password,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
@@ -49,8 +107,80 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const deleteUserDueForDeletion = `-- name: DeleteUserDueForDeletion :execrows
+DELETE FROM users
+WHERE id = ? AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?
+`
+
+type DeleteUserDueForDeletionParams struct {
+	ID                  string       `json:"id"`
+	DeletionScheduledAt sql.NullTime `json:"deletion_scheduled_at"`
+}
+
+func (q *Queries) DeleteUserDueForDeletion(ctx context.Context, arg DeleteUserDueForDeletionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserDueForDeletion, arg.ID, arg.DeletionScheduledAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const disableUser = `-- name: DisableUser :exec
+UPDATE users
+SET disabled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) DisableUser(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, disableUser, id)
+	return err
+}
+
+const disableUserTOTP = `-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) DisableUserTOTP(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, disableUserTOTP, id)
+	return err
+}
+
+const enableUser = `-- name: EnableUser :exec
+UPDATE users
+SET disabled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) EnableUser(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, enableUser, id)
+	return err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_enabled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, enableUserTOTP, id)
+	return err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, password FROM users
+SELECT id, created_at, updated_at, email, password, email_verified_at, totp_secret, totp_enabled_at, deletion_scheduled_at, role, disabled_at, totp_last_step FROM users
 WHERE email = ?
 `
 
@@ -63,6 +193,321 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.UpdatedAt,
 		&i.Email,
 		&i.Password,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, password, email_verified_at, totp_secret, totp_enabled_at, deletion_scheduled_at, role, disabled_at, totp_last_step FROM users
+WHERE id = ?
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.Password,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUsersDueForDeletion = `-- name: GetUsersDueForDeletion :many
+SELECT id, created_at, updated_at, email, password, email_verified_at, totp_secret, totp_enabled_at, deletion_scheduled_at, role, disabled_at, totp_last_step FROM users
+WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?
+`
+
+func (q *Queries) GetUsersDueForDeletion(ctx context.Context, deletionScheduledAt sql.NullTime) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersDueForDeletion, deletionScheduledAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.Password,
+			&i.EmailVerifiedAt,
+			&i.TotpSecret,
+			&i.TotpEnabledAt,
+			&i.DeletionScheduledAt,
+			&i.Role,
+			&i.DisabledAt,
+			&i.TotpLastStep,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersWithTOTPSecret = `-- name: GetUsersWithTOTPSecret :many
+SELECT id, created_at, updated_at, email, password, email_verified_at, totp_secret, totp_enabled_at, deletion_scheduled_at, role, disabled_at, totp_last_step FROM users
+WHERE totp_secret IS NOT NULL
+`
+
+func (q *Queries) GetUsersWithTOTPSecret(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersWithTOTPSecret)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.Password,
+			&i.EmailVerifiedAt,
+			&i.TotpSecret,
+			&i.TotpEnabledAt,
+			&i.DeletionScheduledAt,
+			&i.Role,
+			&i.DisabledAt,
+			&i.TotpLastStep,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, created_at, updated_at, email, password, email_verified_at, totp_secret, totp_enabled_at, deletion_scheduled_at, role, disabled_at, totp_last_step FROM users
+ORDER BY created_at
+LIMIT ? OFFSET ?
+`
+
+type ListUsersParams struct {
+	Limit  int64 `json:"limit"`
+	Offset int64 `json:"offset"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.Password,
+			&i.EmailVerifiedAt,
+			&i.TotpSecret,
+			&i.TotpEnabledAt,
+			&i.DeletionScheduledAt,
+			&i.Role,
+			&i.DisabledAt,
+			&i.TotpLastStep,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :execrows
+UPDATE users
+SET email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND email = ?
+`
+
+type MarkUserEmailVerifiedParams struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markUserEmailVerified, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const replaceUserTOTPSecret = `-- name: ReplaceUserTOTPSecret :execrows
+UPDATE users
+SET totp_secret = ?1
+WHERE id = ?2 AND totp_secret = ?3
+`
+
+type ReplaceUserTOTPSecretParams struct {
+	NewSecret sql.NullString `json:"new_secret"`
+	ID        string         `json:"id"`
+	OldSecret sql.NullString `json:"old_secret"`
+}
+
+func (q *Queries) ReplaceUserTOTPSecret(ctx context.Context, arg ReplaceUserTOTPSecretParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, replaceUserTOTPSecret, arg.NewSecret, arg.ID, arg.OldSecret)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type ScheduleUserDeletionParams struct {
+	DeletionScheduledAt sql.NullTime `json:"deletion_scheduled_at"`
+	ID                  string       `json:"id"`
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) error {
+	_, err := q.db.ExecContext(ctx, scheduleUserDeletion, arg.DeletionScheduledAt, arg.ID)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :exec
+UPDATE users
+SET role = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type SetUserRoleParams struct {
+	Role string `json:"role"`
+	ID   string `json:"id"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, setUserRole, arg.Role, arg.ID)
+	return err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :exec
+UPDATE users
+SET totp_secret = ?, totp_enabled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type SetUserTOTPSecretParams struct {
+	TotpSecret sql.NullString `json:"-"`
+	ID         string         `json:"id"`
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) error {
+	_, err := q.db.ExecContext(ctx, setUserTOTPSecret, arg.TotpSecret, arg.ID)
+	return err
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :one
+UPDATE users
+SET email = ?, email_verified_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, created_at, updated_at, email, password, email_verified_at, totp_secret, totp_enabled_at, deletion_scheduled_at, role, disabled_at, totp_last_step
+`
+
+type UpdateUserEmailParams struct {
+	Email string `json:"email"`
+	ID    string `json:"id"`
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserEmail, arg.Email, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.Password,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateUserPasswordParams struct {
+	Password string `json:"password"`
+	ID       string `json:"id"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.Password, arg.ID)
+	return err
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = ?1, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?2 AND totp_last_step < ?1
+`
+
+type UseTOTPStepParams struct {
+	Step int64  `json:"step"`
+	ID   string `json:"id"`
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTOTPStep, arg.Step, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
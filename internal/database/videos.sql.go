@@ -10,22 +10,24 @@ import (
 )
 
 const createVideo = `-- name: CreateVideo :one
-INSERT INTO videos(id, created_at, updated_at, title, description, user_id)
+INSERT INTO videos(id, created_at, updated_at, title, description, user_id, organization_id)
 VALUES (
     ?,
     CURRENT_TIMESTAMP,
     CURRENT_TIMESTAMP,
     ?,
     ?,
+    ?,
     ?
-) RETURNING id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id
+) RETURNING id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, organization_id
 `
 
 type CreateVideoParams struct {
-	ID          string `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	UserID      string `json:"user_id"`
+	ID             string  `json:"id"`
+	Title          string  `json:"title"`
+	Description    string  `json:"description"`
+	UserID         *string `json:"user_id"`
+	OrganizationID string  `json:"organization_id"`
 }
 
 func (q *Queries) CreateVideo(ctx context.Context, arg CreateVideoParams) (Video, error) {
@@ -34,6 +36,7 @@ func (q *Queries) CreateVideo(ctx context.Context, arg CreateVideoParams) (Video
 		arg.Title,
 		arg.Description,
 		arg.UserID,
+		arg.OrganizationID,
 	)
 	var i Video
 	err := row.Scan(
@@ -45,6 +48,7 @@ func (q *Queries) CreateVideo(ctx context.Context, arg CreateVideoParams) (Video
 		&i.Title,
 		&i.Description,
 		&i.UserID,
+		&i.OrganizationID,
 	)
 	return i, err
 }
@@ -62,21 +66,26 @@ const deleteVideo = `-- name: DeleteVideo :exec
 ;
 
 DELETE FROM videos 
-WHERE id = ? AND user_id = ?
+WHERE id = ?
 `
 
-type DeleteVideoParams struct {
-	ID     string `json:"id"`
-	UserID string `json:"user_id"`
+func (q *Queries) DeleteVideo(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteVideo, id)
+	return err
 }
 
-func (q *Queries) DeleteVideo(ctx context.Context, arg DeleteVideoParams) error {
-	_, err := q.db.ExecContext(ctx, deleteVideo, arg.ID, arg.UserID)
+const deleteVideosByOrganization = `-- name: DeleteVideosByOrganization :exec
+DELETE FROM videos
+WHERE organization_id = ?
+`
+
+func (q *Queries) DeleteVideosByOrganization(ctx context.Context, organizationID string) error {
+	_, err := q.db.ExecContext(ctx, deleteVideosByOrganization, organizationID)
 	return err
 }
 
 const getVideo = `-- name: GetVideo :one
-SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id FROM videos WHERE id = ?
+SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, organization_id FROM videos WHERE id = ?
 `
 
 func (q *Queries) GetVideo(ctx context.Context, id string) (Video, error) {
@@ -91,15 +100,92 @@ func (q *Queries) GetVideo(ctx context.Context, id string) (Video, error) {
 		&i.Title,
 		&i.Description,
 		&i.UserID,
+		&i.OrganizationID,
 	)
 	return i, err
 }
 
+const getVideosByMember = `-- name: GetVideosByMember :many
+SELECT videos.id, videos.created_at, videos.updated_at, videos.thumbnail_url, videos.video_url, videos.title, videos.description, videos.user_id, videos.organization_id FROM videos
+JOIN organization_members ON organization_members.organization_id = videos.organization_id
+WHERE organization_members.user_id = ?
+`
+
+func (q *Queries) GetVideosByMember(ctx context.Context, userID string) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, getVideosByMember, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Video
+	for rows.Next() {
+		var i Video
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ThumbnailUrl,
+			&i.VideoUrl,
+			&i.Title,
+			&i.Description,
+			&i.UserID,
+			&i.OrganizationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVideosByOrganization = `-- name: GetVideosByOrganization :many
+SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, organization_id FROM videos WHERE organization_id = ?
+`
+
+func (q *Queries) GetVideosByOrganization(ctx context.Context, organizationID string) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, getVideosByOrganization, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Video
+	for rows.Next() {
+		var i Video
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ThumbnailUrl,
+			&i.VideoUrl,
+			&i.Title,
+			&i.Description,
+			&i.UserID,
+			&i.OrganizationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getVideosByUser = `-- name: GetVideosByUser :many
-SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id FROM videos WHERE user_id = ?
+SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, organization_id FROM videos WHERE user_id = ?
 `
 
-func (q *Queries) GetVideosByUser(ctx context.Context, userID string) ([]Video, error) {
+func (q *Queries) GetVideosByUser(ctx context.Context, userID *string) ([]Video, error) {
 	rows, err := q.db.QueryContext(ctx, getVideosByUser, userID)
 	if err != nil {
 		return nil, err
@@ -117,6 +203,51 @@ func (q *Queries) GetVideosByUser(ctx context.Context, userID string) ([]Video,
 			&i.Title,
 			&i.Description,
 			&i.UserID,
+			&i.OrganizationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVideos = `-- name: ListVideos :many
+SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, organization_id FROM videos
+ORDER BY created_at DESC
+LIMIT ? OFFSET ?
+`
+
+type ListVideosParams struct {
+	Limit  int64 `json:"limit"`
+	Offset int64 `json:"offset"`
+}
+
+func (q *Queries) ListVideos(ctx context.Context, arg ListVideosParams) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, listVideos, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Video
+	for rows.Next() {
+		var i Video
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ThumbnailUrl,
+			&i.VideoUrl,
+			&i.Title,
+			&i.Description,
+			&i.UserID,
+			&i.OrganizationID,
 		); err != nil {
 			return nil, err
 		}
@@ -135,7 +266,7 @@ const updateVideoThumbnail = `-- name: UpdateVideoThumbnail :one
 UPDATE videos
 SET thumbnail_url = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id
+RETURNING id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, organization_id
 `
 
 type UpdateVideoThumbnailParams struct {
@@ -155,6 +286,7 @@ func (q *Queries) UpdateVideoThumbnail(ctx context.Context, arg UpdateVideoThumb
 		&i.Title,
 		&i.Description,
 		&i.UserID,
+		&i.OrganizationID,
 	)
 	return i, err
 }
@@ -165,7 +297,7 @@ const updateVideoUrl = `-- name: UpdateVideoUrl :one
 UPDATE videos
 SET video_url = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id
+RETURNING id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, organization_id
 `
 
 type UpdateVideoUrlParams struct {
@@ -185,6 +317,7 @@ func (q *Queries) UpdateVideoUrl(ctx context.Context, arg UpdateVideoUrlParams)
 		&i.Title,
 		&i.Description,
 		&i.UserID,
+		&i.OrganizationID,
 	)
 	return i, err
 }
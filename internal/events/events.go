@@ -0,0 +1,112 @@
+// Package events publishes the domain events recorded in the outbox to the
+// systems following them: a NATS subject, a Redis stream, or a log file
+// standing in for either in local development.
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// DialTimeout bounds connecting to a broker when the context has no deadline.
+const DialTimeout time.Duration = time.Second * 10
+
+// Event is a change to a video or user, as sinks receive it.
+type Event struct {
+	// ID is unique per event and stays the same when it's published again.
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	UserID        string          `json:"user_id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// Sink receives published events. Publish only returns nil once the event is
+// safely handed over, otherwise it gets published again later, so sinks see
+// every event at least once and consumers should skip IDs they've seen.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event Event) error
+}
+
+// LogSink writes events as JSON lines to a file (or stdout) instead of a
+// broker. It is meant for local development.
+type LogSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogSink(path string) (*LogSink, error) {
+	if path == "" {
+		return &LogSink{w: os.Stdout}, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log file: %w", err)
+	}
+	return &LogSink{w: file}, nil
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Publish(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+// brokerConn is a connection to a broker speaking a line based protocol. It's
+// dropped on any error and dialed again on the next publish.
+type brokerConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialBroker(ctx context.Context, addr string) (*brokerConn, error) {
+	dialer := net.Dialer{Timeout: DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &brokerConn{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// deadline makes reads and writes give up with ctx.
+func (c *brokerConn) deadline(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
+	c.conn.SetDeadline(deadline)
+}
+
+// readLine returns the next CRLF terminated line without its terminator.
+func (c *brokerConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("malformed line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+func (c *brokerConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,151 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const NATSDefaultPort string = "4222"
+
+// NATSSink publishes events on "<prefix>.<type>" subjects, e.g.
+// "video-manager.video.created", speaking the NATS client protocol. Each
+// publish is followed by a PING, so it only succeeds once the server has
+// processed it.
+type NATSSink struct {
+	addr     string
+	user     string
+	password string
+	prefix   string
+
+	mu   sync.Mutex
+	conn *brokerConn
+}
+
+// NewNATSSink takes the server as nats://[user:password@]host[:port].
+func NewNATSSink(rawURL, subjectPrefix string) (*NATSSink, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme != "nats" || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("invalid nats url %q, expected nats://host:port", rawURL)
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = NATSDefaultPort
+	}
+	sink := &NATSSink{
+		addr:   net.JoinHostPort(parsed.Hostname(), port),
+		prefix: strings.TrimSuffix(subjectPrefix, "."),
+	}
+	if parsed.User != nil {
+		sink.user = parsed.User.Username()
+		sink.password, _ = parsed.User.Password()
+	}
+	return sink, nil
+}
+
+func (s *NATSSink) Name() string {
+	return "nats"
+}
+
+func (s *NATSSink) Subject(event Event) string {
+	if s.prefix == "" {
+		return event.Type
+	}
+	return s.prefix + "." + event.Type
+}
+
+func (s *NATSSink) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.publish(ctx, s.Subject(event), body); err != nil {
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		return fmt.Errorf("failed to publish to nats: %w", err)
+	}
+	return nil
+}
+
+func (s *NATSSink) publish(ctx context.Context, subject string, body []byte) error {
+	if s.conn == nil {
+		conn, err := s.connect(ctx)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	s.conn.deadline(ctx)
+	msg := fmt.Appendf(nil, "PUB %s %d\r\n", subject, len(body))
+	msg = append(msg, body...)
+	msg = append(msg, "\r\nPING\r\n"...)
+	if _, err := s.conn.conn.Write(msg); err != nil {
+		return err
+	}
+	return s.awaitPong()
+}
+
+// connect reads the server INFO and answers with CONNECT. Verbose mode stays
+// off, errors still come back as -ERR before the PONG.
+func (s *NATSSink) connect(ctx context.Context) (*brokerConn, error) {
+	conn, err := dialBroker(ctx, s.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn.deadline(ctx)
+	info, err := conn.readLine()
+	if err != nil || !strings.HasPrefix(info, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected nats greeting %q: %v", info, err)
+	}
+	options := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "video-manager",
+		"lang":     "go",
+		"protocol": 0,
+	}
+	if s.user != "" {
+		options["user"] = s.user
+		options["pass"] = s.password
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(conn.conn, "CONNECT %s\r\n", connect); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (s *NATSSink) awaitPong() error {
+	for {
+		line, err := s.conn.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		// +OK and INFO updates need no answer.
+	}
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const RedisDefaultPort string = "6379"
+
+// RedisSink appends events to a Redis stream with XADD, one entry per event
+// with its id, type and JSON encoding, speaking RESP.
+type RedisSink struct {
+	addr     string
+	user     string
+	password string
+	db       int
+	stream   string
+
+	mu   sync.Mutex
+	conn *brokerConn
+}
+
+// NewRedisSink takes the server as redis://[[user]:password@]host[:port][/db].
+func NewRedisSink(rawURL, stream string) (*RedisSink, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme != "redis" || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("invalid redis url %q, expected redis://host:port", rawURL)
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = RedisDefaultPort
+	}
+	sink := &RedisSink{
+		addr:   net.JoinHostPort(parsed.Hostname(), port),
+		stream: stream,
+	}
+	if parsed.User != nil {
+		sink.user = parsed.User.Username()
+		sink.password, _ = parsed.User.Password()
+	}
+	if db := strings.Trim(parsed.Path, "/"); db != "" {
+		if sink.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+	return sink, nil
+}
+
+func (s *RedisSink) Name() string {
+	return "redis"
+}
+
+func (s *RedisSink) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.publish(ctx, event, body); err != nil {
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		return fmt.Errorf("failed to publish to redis: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisSink) publish(ctx context.Context, event Event, body []byte) error {
+	if s.conn == nil {
+		conn, err := s.connect(ctx)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	s.conn.deadline(ctx)
+	_, err := redisCommand(s.conn, "XADD", s.stream, "*", "id", event.ID, "type", event.Type, "event", string(body))
+	return err
+}
+
+// connect authenticates and selects the database, when the URL asks for it.
+func (s *RedisSink) connect(ctx context.Context) (*brokerConn, error) {
+	conn, err := dialBroker(ctx, s.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn.deadline(ctx)
+	if s.password != "" {
+		args := []string{"AUTH", s.password}
+		if s.user != "" {
+			args = []string{"AUTH", s.user, s.password}
+		}
+		if _, err := redisCommand(conn, args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+	if s.db != 0 {
+		if _, err := redisCommand(conn, "SELECT", strconv.Itoa(s.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select database: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// redisCommand sends a command and reads its reply: a simple string, an
+// integer or a bulk string. Error replies are returned as errors.
+func redisCommand(conn *brokerConn, args ...string) (string, error) {
+	cmd := fmt.Appendf(nil, "*%d\r\n", len(args))
+	for _, arg := range args {
+		cmd = fmt.Appendf(cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.conn.Write(cmd); err != nil {
+		return "", err
+	}
+	line, err := conn.readLine()
+	if err != nil {
+		return "", err
+	}
+	if line == "" {
+		return "", errors.New("empty redis reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", errors.New(line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("malformed redis reply %q", line)
+		}
+		if size < 0 {
+			return "", nil
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(conn.r, value); err != nil {
+			return "", err
+		}
+		return string(value[:size]), nil
+	default:
+		return "", fmt.Errorf("unexpected redis reply %q", line)
+	}
+}
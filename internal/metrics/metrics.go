@@ -0,0 +1,191 @@
+// Package metrics holds the Prometheus collectors exposed on /metrics.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	Namespace      string = "video_manager"
+	OutcomeSuccess string = "success"
+	OutcomeError   string = "error"
+)
+
+var (
+	Registry = prometheus.NewRegistry()
+
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time spent serving HTTP requests, by route pattern.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"method", "route"})
+
+	UploadBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "upload_bytes_total",
+		Help:      "Bytes received through uploads, by kind of media.",
+	}, []string{"kind"})
+
+	SubprocessDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "subprocess_duration_seconds",
+		Help:      "Run time of ffmpeg and ffprobe invocations.",
+		Buckets:   prometheus.ExponentialBuckets(.05, 2, 12),
+	}, []string{"command"})
+
+	SubprocessFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "subprocess_failures_total",
+		Help:      "ffmpeg and ffprobe invocations that failed.",
+	}, []string{"command"})
+
+	StorageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "storage_operation_duration_seconds",
+		Help:      "Latency of blob storage operations, by operation and outcome.",
+		Buckets:   prometheus.ExponentialBuckets(.01, 2, 14),
+	}, []string{"operation", "outcome"})
+
+	JobQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "job_queue_depth",
+		Help:      "Items waiting to be processed by background jobs, by queue.",
+	}, []string{"queue"})
+
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Webhook delivery attempts, by outcome.",
+	}, []string{"outcome"})
+
+	EventsPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "events_published_total",
+		Help:      "Outbox events handed to sinks, by sink and outcome.",
+	}, []string{"sink", "outcome"})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of database queries, by sqlc query name and outcome.",
+		Buckets:   prometheus.ExponentialBuckets(.0005, 2, 14),
+	}, []string{"query", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		UploadBytes,
+		SubprocessDuration,
+		SubprocessFailures,
+		StorageDuration,
+		JobQueueDepth,
+		WebhookDeliveries,
+		EventsPublished,
+		DBQueryDuration,
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
+
+// ObserveSubprocess records a finished ffmpeg/ffprobe run started at start.
+func ObserveSubprocess(command string, start time.Time, err error) {
+	SubprocessDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+	if err != nil {
+		SubprocessFailures.WithLabelValues(command).Inc()
+	}
+}
+
+// ObserveStorage records a finished blob storage operation started at start.
+func ObserveStorage(operation string, start time.Time, err error) {
+	StorageDuration.WithLabelValues(operation, outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+// DBTX mirrors database.DBTX so this package doesn't depend on it.
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// InstrumentDB times every query run through db.
+func InstrumentDB(db DBTX) DBTX {
+	return instrumentedDB{db}
+}
+
+type instrumentedDB struct {
+	db DBTX
+}
+
+func (i instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := i.db.ExecContext(ctx, query, args...)
+	observeQuery(query, start, err)
+	return result, err
+}
+
+func (i instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return i.db.PrepareContext(ctx, query)
+}
+
+func (i instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := i.db.QueryContext(ctx, query, args...)
+	observeQuery(query, start, err)
+	return rows, err
+}
+
+func (i instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := i.db.QueryRowContext(ctx, query, args...)
+	err := row.Err()
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	observeQuery(query, start, err)
+	return row
+}
+
+func observeQuery(query string, start time.Time, err error) {
+	DBQueryDuration.WithLabelValues(QueryName(query), outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+// QueryName extracts the name sqlc puts at the top of every generated query
+// ("-- name: GetVideo :one"), so labels stay bounded.
+func QueryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "unknown"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
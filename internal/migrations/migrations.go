@@ -0,0 +1,109 @@
+// Package migrations applies the embedded schema migrations and checks that a
+// database matches the schema this build was generated from.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	postgresschema "github.com/charlesaraya/video-manager-go/internal/sql/postgres/schema"
+	"github.com/charlesaraya/video-manager-go/internal/sql/schema"
+	"github.com/pressly/goose/v3"
+)
+
+const (
+	DriverSQLite   string = "sqlite"
+	DriverPostgres string = "postgres"
+)
+
+var ErrSchemaMismatch = errors.New("database schema version mismatch")
+
+// Status is the state of a single migration in a database.
+type Status = goose.MigrationStatus
+
+// newProvider loads the migrations written for driver.
+func newProvider(driver string, db *sql.DB) (*goose.Provider, error) {
+	var dialect goose.Dialect
+	var migrations fs.FS
+	switch driver {
+	case DriverSQLite:
+		dialect, migrations = goose.DialectSQLite3, schema.FS
+	case DriverPostgres:
+		dialect, migrations = goose.DialectPostgres, postgresschema.FS
+	default:
+		return nil, fmt.Errorf("unknown database driver %q (%s or %s)", driver, DriverSQLite, DriverPostgres)
+	}
+	provider, err := goose.NewProvider(dialect, db, migrations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return provider, nil
+}
+
+// Up applies every pending migration and returns the versions it applied.
+func Up(ctx context.Context, driver string, db *sql.DB) ([]int64, error) {
+	provider, err := newProvider(driver, db)
+	if err != nil {
+		return nil, err
+	}
+	results, err := provider.Up(ctx)
+	applied := make([]int64, 0, len(results))
+	for _, result := range results {
+		if result.Error == nil {
+			applied = append(applied, result.Source.Version)
+		}
+	}
+	if err != nil {
+		return applied, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// Down rolls back the latest applied migration and returns its version.
+func Down(ctx context.Context, driver string, db *sql.DB) (int64, error) {
+	provider, err := newProvider(driver, db)
+	if err != nil {
+		return 0, err
+	}
+	result, err := provider.Down(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll back migration: %w", err)
+	}
+	return result.Source.Version, nil
+}
+
+// List returns the state of every embedded migration in db.
+func List(ctx context.Context, driver string, db *sql.DB) ([]*Status, error) {
+	provider, err := newProvider(driver, db)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration status: %w", err)
+	}
+	return statuses, nil
+}
+
+// CheckVersion fails with ErrSchemaMismatch unless db is at the latest
+// embedded migration: behind means it still needs migrating, ahead means it
+// was migrated by a newer build.
+func CheckVersion(ctx context.Context, driver string, db *sql.DB) error {
+	provider, err := newProvider(driver, db)
+	if err != nil {
+		return err
+	}
+	current, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read database schema version: %w", err)
+	}
+	sources := provider.ListSources()
+	expected := sources[len(sources)-1].Version
+	if current != expected {
+		return fmt.Errorf("%w: database is at version %d, expected %d", ErrSchemaMismatch, current, expected)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ErrInvalidRateSpec string        = "invalid rate spec, expected <requests>/<duration>"
+	pruneInterval      time.Duration = time.Minute
+)
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// Limiter is an in-memory token bucket rate limiter keyed by an arbitrary string
+// (usually the client IP). Each key gets Burst tokens refilled at Rate per second.
+type Limiter struct {
+	Rate  float64
+	Burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		Rate:      rate,
+		Burst:     burst,
+		buckets:   map[string]*bucket{},
+		lastPrune: time.Now(),
+	}
+}
+
+// ParseLimiter builds a Limiter from a spec such as "10/1m": a burst of 10
+// requests, refilled at 10 requests per minute.
+func ParseLimiter(spec string) (*Limiter, error) {
+	count, window, ok := strings.Cut(spec, "/")
+	if !ok {
+		return nil, errors.New(ErrInvalidRateSpec)
+	}
+	burst, err := strconv.Atoi(count)
+	if err != nil || burst <= 0 {
+		return nil, fmt.Errorf("%s: %q", ErrInvalidRateSpec, spec)
+	}
+	duration, err := time.ParseDuration(window)
+	if err != nil || duration <= 0 {
+		return nil, fmt.Errorf("%s: %q", ErrInvalidRateSpec, spec)
+	}
+	return NewLimiter(float64(burst)/duration.Seconds(), burst), nil
+}
+
+// Allow takes a token for key. When none is left it returns false and how long
+// the caller has to wait for the next one.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), lastSeen: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.lastSeen).Seconds()*l.Rate)
+	b.lastSeen = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+	return false, wait
+}
+
+// prune drops buckets that have been idle long enough to be full again.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+	l.lastPrune = now
+	full := time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > full {
+			delete(l.buckets, key)
+		}
+	}
+}
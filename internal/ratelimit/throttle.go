@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type failureRecord struct {
+	failures     int
+	blockedUntil time.Time
+	lastFailure  time.Time
+}
+
+// Throttle slows down repeated failures for a key (an IP or an account) with
+// exponential backoff, and locks the key out after MaxFailures in a row.
+//
+// Failures are kept in memory, so each replica of the server throttles on
+// its own: behind a load balancer an attacker gets MaxFailures per replica.
+type Throttle struct {
+	MaxFailures     int
+	BaseDelay       time.Duration
+	LockoutDuration time.Duration
+
+	mu        sync.Mutex
+	records   map[string]*failureRecord
+	lastPrune time.Time
+}
+
+func NewThrottle(maxFailures int, baseDelay, lockoutDuration time.Duration) *Throttle {
+	return &Throttle{
+		MaxFailures:     maxFailures,
+		BaseDelay:       baseDelay,
+		LockoutDuration: lockoutDuration,
+		records:         map[string]*failureRecord{},
+		lastPrune:       time.Now(),
+	}
+}
+
+// Check returns how long key must wait before its next attempt, or zero.
+func (t *Throttle) Check(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	record, ok := t.records[key]
+	if !ok {
+		return 0
+	}
+	wait := time.Until(record.blockedUntil)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// Failure records a failed attempt for key and returns the resulting wait.
+func (t *Throttle) Failure(key string) time.Duration {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune(now)
+
+	record, ok := t.records[key]
+	if !ok {
+		record = &failureRecord{}
+		t.records[key] = record
+	}
+	record.failures++
+	record.lastFailure = now
+	delay := t.LockoutDuration
+	if record.failures < t.MaxFailures {
+		delay = t.BaseDelay << (record.failures - 1)
+		if delay > t.LockoutDuration || delay <= 0 {
+			delay = t.LockoutDuration
+		}
+	}
+	record.blockedUntil = now.Add(delay)
+	return delay
+}
+
+// Success clears the failure history of key.
+func (t *Throttle) Success(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.records, key)
+}
+
+// prune forgets keys whose last failure is older than a full lockout.
+func (t *Throttle) prune(now time.Time) {
+	if now.Sub(t.lastPrune) < pruneInterval {
+		return
+	}
+	t.lastPrune = now
+	for key, record := range t.records {
+		if now.Sub(record.lastFailure) > t.LockoutDuration && now.After(record.blockedUntil) {
+			delete(t.records, key)
+		}
+	}
+}
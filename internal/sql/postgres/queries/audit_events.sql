@@ -0,0 +1,24 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (created_at, action, actor_id, impersonator_id, ip, target_type, target_id, diff)
+VALUES (
+    CURRENT_TIMESTAMP,
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg('actor_id') IS NULL OR actor_id = sqlc.narg('actor_id'))
+    AND (sqlc.narg('action') IS NULL OR action = sqlc.narg('action'))
+    AND (sqlc.narg('target_type') IS NULL OR target_type = sqlc.narg('target_type'))
+    AND (sqlc.narg('target_id') IS NULL OR target_id = sqlc.narg('target_id'))
+    AND (sqlc.narg('since') IS NULL OR created_at >= sqlc.narg('since'))
+    AND (sqlc.narg('until') IS NULL OR created_at < sqlc.narg('until'))
+    AND (sqlc.narg('before_id') IS NULL OR id < sqlc.narg('before_id'))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')::bigint OFFSET sqlc.arg('offset')::bigint;
@@ -0,0 +1,23 @@
+-- name: CreateEmailVerificationToken :one
+INSERT INTO email_verification_tokens (token_hash, user_id, email, created_at, expires_at, used_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    CURRENT_TIMESTAMP,
+    $4,
+    NULL
+)
+RETURNING *;
+
+-- name: GetEmailVerificationToken :one
+SELECT * FROM email_verification_tokens
+WHERE token_hash = $1;
+
+-- name: UseEmailVerificationToken :execrows
+UPDATE email_verification_tokens
+SET used_at = CURRENT_TIMESTAMP
+WHERE token_hash = $1 AND used_at IS NULL;
+
+-- name: DeleteAllEmailVerificationTokens :exec
+DELETE FROM email_verification_tokens;
@@ -0,0 +1,20 @@
+-- name: CreateMFAChallenge :exec
+INSERT INTO mfa_challenges (challenge_hash, user_id, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    CURRENT_TIMESTAMP,
+    $3
+);
+
+-- name: ConsumeMFAChallenge :one
+DELETE FROM mfa_challenges
+WHERE challenge_hash = $1 AND expires_at > $2
+RETURNING *;
+
+-- name: DeleteExpiredMFAChallenges :exec
+DELETE FROM mfa_challenges
+WHERE expires_at < $1;
+
+-- name: DeleteAllMFAChallenges :exec
+DELETE FROM mfa_challenges;
@@ -0,0 +1,20 @@
+-- name: CreateRecoveryCode :exec
+INSERT INTO mfa_recovery_codes (code_hash, user_id, created_at, used_at)
+VALUES (
+    $1,
+    $2,
+    CURRENT_TIMESTAMP,
+    NULL
+);
+
+-- name: UseRecoveryCode :execrows
+UPDATE mfa_recovery_codes
+SET used_at = CURRENT_TIMESTAMP
+WHERE code_hash = $1 AND user_id = $2 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodesByUser :exec
+DELETE FROM mfa_recovery_codes
+WHERE user_id = $1;
+
+-- name: DeleteAllRecoveryCodes :exec
+DELETE FROM mfa_recovery_codes;
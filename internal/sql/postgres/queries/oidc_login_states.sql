@@ -0,0 +1,21 @@
+-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (state_hash, nonce, code_verifier, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    CURRENT_TIMESTAMP,
+    $4
+);
+
+-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at < $1;
+
+-- name: DeleteAllOIDCLoginStates :exec
+DELETE FROM oidc_login_states;
@@ -0,0 +1,24 @@
+-- name: CreateOrganizationInvitation :exec
+INSERT INTO organization_invitations (token_hash, organization_id, email, role, invited_by, created_at, expires_at, accepted_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    CURRENT_TIMESTAMP,
+    $6,
+    NULL
+);
+
+-- name: GetOrganizationInvitation :one
+SELECT * FROM organization_invitations
+WHERE token_hash = $1;
+
+-- name: AcceptOrganizationInvitation :execrows
+UPDATE organization_invitations
+SET accepted_at = CURRENT_TIMESTAMP
+WHERE token_hash = $1 AND accepted_at IS NULL;
+
+-- name: DeleteAllOrganizationInvitations :exec
+DELETE FROM organization_invitations;
@@ -0,0 +1,45 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (event_id, created_at, event_type, aggregate_type, aggregate_id, user_id, payload, next_attempt_at)
+VALUES (
+    $1,
+    CURRENT_TIMESTAMP,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
+
+-- name: ClaimOutboxEvents :many
+UPDATE outbox_events
+SET next_attempt_at = sqlc.arg('lease_until')
+WHERE id IN (
+    SELECT id FROM outbox_events
+    WHERE published_at IS NULL AND next_attempt_at <= sqlc.arg('now')
+    ORDER BY id
+    LIMIT sqlc.arg('limit')::bigint
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = $1, attempts = attempts + 1, last_error = ''
+WHERE id = $2;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE outbox_events
+SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2
+WHERE id = $3;
+
+-- name: CountPendingOutboxEvents :one
+SELECT COUNT(*) FROM outbox_events
+WHERE published_at IS NULL;
+
+-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM outbox_events
+WHERE published_at < $1;
+
+-- name: DeleteAllOutboxEvents :exec
+DELETE FROM outbox_events;
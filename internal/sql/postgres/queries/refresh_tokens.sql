@@ -0,0 +1,36 @@
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (token, user_id, created_at, updated_at, expires_at, revoked_at)
+VALUES (
+    $1,
+    $2,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    $3,
+    NULL
+)
+RETURNING *;
+
+-- name: GetRefreshToken :one
+SELECT * FROM refresh_tokens
+WHERE token = $1;
+
+-- name: RevokeRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE token = $1 AND revoked_at IS NULL;
+
+-- name: DeleteRefreshToken :exec
+DELETE FROM refresh_tokens
+WHERE token = $1;
+
+-- name: DeleteAllRefreshTokens :exec
+DELETE FROM refresh_tokens;
+
+-- name: RevokeAllRefreshTokensByUser :exec
+UPDATE refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: DeleteRefreshTokensByUser :exec
+DELETE FROM refresh_tokens
+WHERE user_id = $1;
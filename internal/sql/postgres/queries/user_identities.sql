@@ -0,0 +1,18 @@
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (issuer, subject, user_id, email, created_at, updated_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP
+)
+RETURNING *;
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities
+WHERE issuer = $1 AND subject = $2;
+
+-- name: DeleteAllUserIdentities :exec
+DELETE FROM user_identities;
@@ -0,0 +1,120 @@
+-- name: CreateUser :one
+INSERT INTO users (id, created_at, updated_at, email, password)
+VALUES (
+    $1,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1;
+
+-- name: DeleteAllUsers :exec
+DELETE FROM users;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2;
+
+-- name: MarkUserEmailVerified :execrows
+UPDATE users
+SET email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND email = $2;
+
+-- name: SetUserTOTPSecret :exec
+UPDATE users
+SET totp_secret = $1, totp_enabled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2;
+
+-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_enabled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: CreateVerifiedUser :one
+INSERT INTO users (id, created_at, updated_at, email, password, email_verified_at)
+VALUES (
+    $1,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    $2,
+    $3,
+    CURRENT_TIMESTAMP
+)
+RETURNING *;
+
+-- name: UpdateUserEmail :one
+UPDATE users
+SET email = $1, email_verified_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2
+RETURNING *;
+
+-- name: ScheduleUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2;
+
+-- name: CancelUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: GetUsersDueForDeletion :many
+SELECT * FROM users
+WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= sqlc.narg('deletion_scheduled_at');
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1;
+
+-- name: DeleteUserDueForDeletion :execrows
+DELETE FROM users
+WHERE id = $1 AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= $2;
+
+-- name: ListUsers :many
+SELECT * FROM users
+ORDER BY created_at
+LIMIT $1::bigint OFFSET $2::bigint;
+
+-- name: SetUserRole :exec
+UPDATE users
+SET role = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2;
+
+-- name: DisableUser :exec
+UPDATE users
+SET disabled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: EnableUser :exec
+UPDATE users
+SET disabled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = sqlc.arg('step'), updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg('id') AND totp_last_step < sqlc.arg('step');
+
+-- name: GetUsersWithTOTPSecret :many
+SELECT * FROM users
+WHERE totp_secret IS NOT NULL;
+
+-- name: ReplaceUserTOTPSecret :execrows
+UPDATE users
+SET totp_secret = sqlc.arg('new_secret')
+WHERE id = sqlc.arg('id') AND totp_secret = sqlc.arg('old_secret');
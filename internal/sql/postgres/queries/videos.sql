@@ -0,0 +1,53 @@
+-- name: CreateVideo :one
+INSERT INTO videos(id, created_at, updated_at, title, description, user_id, organization_id)
+VALUES (
+    $1,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    $2,
+    $3,
+    $4,
+    $5
+) RETURNING *;
+
+-- name: GetVideosByUser :many
+SELECT * FROM videos WHERE user_id = $1;
+
+-- name: GetVideosByOrganization :many
+SELECT * FROM videos WHERE organization_id = $1;
+
+-- name: GetVideosByMember :many
+SELECT videos.* FROM videos
+JOIN organization_members ON organization_members.organization_id = videos.organization_id
+WHERE organization_members.user_id = $1;
+
+-- name: GetVideo :one
+SELECT * FROM videos WHERE id = $1;
+
+-- name: UpdateVideoThumbnail :one
+UPDATE videos
+SET thumbnail_url = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2
+RETURNING *;
+
+-- name: UpdateVideoUrl :one
+UPDATE videos
+SET video_url = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2
+RETURNING *;
+
+-- name: DeleteVideo :exec
+DELETE FROM videos 
+WHERE id = $1;
+
+-- name: DeleteAllVideos :exec
+DELETE FROM videos;
+
+-- name: DeleteVideosByOrganization :exec
+DELETE FROM videos
+WHERE organization_id = $1;
+
+-- name: ListVideos :many
+SELECT * FROM videos
+ORDER BY created_at DESC
+LIMIT $1::bigint OFFSET $2::bigint;
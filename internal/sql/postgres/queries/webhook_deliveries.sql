@@ -0,0 +1,68 @@
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (id, created_at, updated_at, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at)
+VALUES (
+    $1,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    $2,
+    $3,
+    $4,
+    $5,
+    'pending',
+    0,
+    $6
+)
+ON CONFLICT (subscription_id, event_id) DO NOTHING;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 AND subscription_id = $2;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = $1
+ORDER BY created_at DESC
+LIMIT $2::bigint OFFSET $3::bigint;
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg('lease_until'), updated_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= sqlc.arg('now')
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg('limit')::bigint
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CountPendingWebhookDeliveries :one
+SELECT COUNT(*) FROM webhook_deliveries
+WHERE status = 'pending';
+
+-- name: RecordWebhookDeliveryAttempt :exec
+UPDATE webhook_deliveries
+SET status = $1, attempts = attempts + 1, next_attempt_at = $2, last_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $3;
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2 AND subscription_id = $3
+RETURNING *;
+
+-- name: CreateWebhookDeliveryAttempt :exec
+INSERT INTO webhook_delivery_attempts (delivery_id, created_at, response_status, response_body, error, duration_ms)
+VALUES (
+    $1,
+    CURRENT_TIMESTAMP,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: GetWebhookDeliveryAttempts :many
+SELECT * FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY id;
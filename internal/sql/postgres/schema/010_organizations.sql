@@ -0,0 +1,50 @@
+-- +goose Up
+CREATE TABLE organizations(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE organization_members(
+    organization_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (organization_id, user_id),
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE organization_invitations(
+    token_hash TEXT PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    invited_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Every existing user gets a personal organization sharing their id, which
+-- takes over the videos they own.
+INSERT INTO organizations (id, created_at, updated_at, name)
+SELECT id, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, email FROM users;
+
+INSERT INTO organization_members (organization_id, user_id, role, created_at, updated_at)
+SELECT id, id, 'owner', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM users;
+
+ALTER TABLE videos ADD COLUMN organization_id TEXT REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE videos SET organization_id = user_id;
+ALTER TABLE videos ALTER COLUMN organization_id SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE videos DROP COLUMN organization_id;
+
+DROP TABLE organization_invitations;
+DROP TABLE organization_members;
+DROP TABLE organizations;
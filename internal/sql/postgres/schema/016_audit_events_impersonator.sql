@@ -0,0 +1,10 @@
+-- +goose Up
+-- Entries recorded while an admin impersonates a user keep the admin who
+-- acted, next to the impersonated user as actor.
+ALTER TABLE audit_events ADD COLUMN impersonator_id TEXT;
+
+CREATE INDEX audit_events_impersonator_id_idx ON audit_events(impersonator_id);
+
+-- +goose Down
+DROP INDEX audit_events_impersonator_id_idx;
+ALTER TABLE audit_events DROP COLUMN impersonator_id;
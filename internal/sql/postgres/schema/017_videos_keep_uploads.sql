@@ -0,0 +1,15 @@
+-- +goose Up
+-- Videos belong to their organization. Deleting the account that uploaded one
+-- leaves it in a shared library without an uploader.
+ALTER TABLE videos DROP CONSTRAINT videos_user_id_fkey;
+ALTER TABLE videos ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE videos ADD CONSTRAINT videos_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+-- Videos without an uploader can't be kept.
+DELETE FROM videos WHERE user_id IS NULL;
+ALTER TABLE videos DROP CONSTRAINT videos_user_id_fkey;
+ALTER TABLE videos ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE videos ADD CONSTRAINT videos_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
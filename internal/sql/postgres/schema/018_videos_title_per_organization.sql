@@ -0,0 +1,9 @@
+-- +goose Up
+-- Titles only have to be unique within the library of an organization.
+ALTER TABLE videos DROP CONSTRAINT videos_title_key;
+ALTER TABLE videos ADD CONSTRAINT videos_organization_id_title_key UNIQUE (organization_id, title);
+
+-- +goose Down
+-- Fails while two organizations use the same title, rename one of them first.
+ALTER TABLE videos DROP CONSTRAINT videos_organization_id_title_key;
+ALTER TABLE videos ADD CONSTRAINT videos_title_key UNIQUE (title);
@@ -0,0 +1,8 @@
+// Package schema embeds the Postgres port of the goose migrations, kept at the
+// same versions as the SQLite ones.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,20 @@
+-- name: CreateMFAChallenge :exec
+INSERT INTO mfa_challenges (challenge_hash, user_id, created_at, expires_at)
+VALUES (
+    ?,
+    ?,
+    CURRENT_TIMESTAMP,
+    ?
+);
+
+-- name: ConsumeMFAChallenge :one
+DELETE FROM mfa_challenges
+WHERE challenge_hash = ? AND expires_at > ?
+RETURNING *;
+
+-- name: DeleteExpiredMFAChallenges :exec
+DELETE FROM mfa_challenges
+WHERE expires_at < ?;
+
+-- name: DeleteAllMFAChallenges :exec
+DELETE FROM mfa_challenges;
@@ -0,0 +1,21 @@
+-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (state_hash, nonce, code_verifier, created_at, expires_at)
+VALUES (
+    ?,
+    ?,
+    ?,
+    CURRENT_TIMESTAMP,
+    ?
+);
+
+-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = ?
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at < ?;
+
+-- name: DeleteAllOIDCLoginStates :exec
+DELETE FROM oidc_login_states;
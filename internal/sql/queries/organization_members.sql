@@ -0,0 +1,40 @@
+-- name: AddOrganizationMember :exec
+INSERT INTO organization_members (organization_id, user_id, role, created_at, updated_at)
+VALUES (
+    ?,
+    ?,
+    ?,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP
+);
+
+-- name: GetOrganizationMember :one
+SELECT * FROM organization_members
+WHERE organization_id = ? AND user_id = ?;
+
+-- name: GetOrganizationMembers :many
+SELECT organization_members.organization_id, organization_members.user_id, organization_members.role, organization_members.created_at, organization_members.updated_at, users.email
+FROM organization_members
+JOIN users ON users.id = organization_members.user_id
+WHERE organization_members.organization_id = ?
+ORDER BY organization_members.created_at;
+
+-- name: CountOrganizationOwners :one
+SELECT COUNT(*) FROM organization_members
+WHERE organization_id = ? AND role = 'owner';
+
+-- name: UpdateOrganizationMemberRole :exec
+UPDATE organization_members
+SET role = ?, updated_at = CURRENT_TIMESTAMP
+WHERE organization_id = ? AND user_id = ?;
+
+-- name: RemoveOrganizationMember :exec
+DELETE FROM organization_members
+WHERE organization_id = ? AND user_id = ?;
+
+-- name: DeleteMembershipsByUser :exec
+DELETE FROM organization_members
+WHERE user_id = ?;
+
+-- name: DeleteAllOrganizationMembers :exec
+DELETE FROM organization_members;
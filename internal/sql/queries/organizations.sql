@@ -0,0 +1,27 @@
+-- name: CreateOrganization :one
+INSERT INTO organizations (id, created_at, updated_at, name)
+VALUES (
+    ?,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    ?
+)
+RETURNING *;
+
+-- name: GetOrganization :one
+SELECT * FROM organizations
+WHERE id = ?;
+
+-- name: GetOrganizationsByMember :many
+SELECT organizations.id, organizations.created_at, organizations.updated_at, organizations.name, organization_members.role
+FROM organizations
+JOIN organization_members ON organization_members.organization_id = organizations.id
+WHERE organization_members.user_id = ?
+ORDER BY organizations.created_at;
+
+-- name: DeleteOrganization :exec
+DELETE FROM organizations
+WHERE id = ?;
+
+-- name: DeleteAllOrganizations :exec
+DELETE FROM organizations;
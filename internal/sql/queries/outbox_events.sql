@@ -0,0 +1,44 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (event_id, created_at, event_type, aggregate_type, aggregate_id, user_id, payload, next_attempt_at)
+VALUES (
+    ?,
+    CURRENT_TIMESTAMP,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?
+);
+
+-- name: ClaimOutboxEvents :many
+UPDATE outbox_events
+SET next_attempt_at = sqlc.arg('lease_until')
+WHERE id IN (
+    SELECT id FROM outbox_events
+    WHERE published_at IS NULL AND next_attempt_at <= sqlc.arg('now')
+    ORDER BY id
+    LIMIT sqlc.arg('limit')
+)
+RETURNING *;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = ?, attempts = attempts + 1, last_error = ''
+WHERE id = ?;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE outbox_events
+SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+WHERE id = ?;
+
+-- name: CountPendingOutboxEvents :one
+SELECT COUNT(*) FROM outbox_events
+WHERE published_at IS NULL;
+
+-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM outbox_events
+WHERE published_at < ?;
+
+-- name: DeleteAllOutboxEvents :exec
+DELETE FROM outbox_events;
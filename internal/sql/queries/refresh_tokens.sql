@@ -14,10 +14,10 @@ RETURNING *;
 SELECT * FROM refresh_tokens
 WHERE token = ?;
 
--- name: RevokeRefreshToken :exec
+-- name: RevokeRefreshToken :execrows
 UPDATE refresh_tokens
 SET revoked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
-WHERE token = ?;
+WHERE token = ? AND revoked_at IS NULL;
 
 -- name: DeleteRefreshToken :exec
 DELETE FROM refresh_tokens
@@ -25,3 +25,12 @@ WHERE token = ?;
 
 -- name: DeleteAllRefreshTokens :exec
 DELETE FROM refresh_tokens;
+
+-- name: RevokeAllRefreshTokensByUser :exec
+UPDATE refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND revoked_at IS NULL;
+
+-- name: DeleteRefreshTokensByUser :exec
+DELETE FROM refresh_tokens
+WHERE user_id = ?;
@@ -0,0 +1,18 @@
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (issuer, subject, user_id, email, created_at, updated_at)
+VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP
+)
+RETURNING *;
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities
+WHERE issuer = ? AND subject = ?;
+
+-- name: DeleteAllUserIdentities :exec
+DELETE FROM user_identities;
@@ -15,3 +15,106 @@ WHERE email = ?;
 
 -- name: DeleteAllUsers :exec
 DELETE FROM users;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = ?;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: MarkUserEmailVerified :execrows
+UPDATE users
+SET email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND email = ?;
+
+-- name: SetUserTOTPSecret :exec
+UPDATE users
+SET totp_secret = ?, totp_enabled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_enabled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: CreateVerifiedUser :one
+INSERT INTO users (id, created_at, updated_at, email, password, email_verified_at)
+VALUES (
+    ?,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    ?,
+    ?,
+    CURRENT_TIMESTAMP
+)
+RETURNING *;
+
+-- name: UpdateUserEmail :one
+UPDATE users
+SET email = ?, email_verified_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: ScheduleUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: CancelUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: GetUsersDueForDeletion :many
+SELECT * FROM users
+WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = ?;
+
+-- name: DeleteUserDueForDeletion :execrows
+DELETE FROM users
+WHERE id = ? AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?;
+
+-- name: ListUsers :many
+SELECT * FROM users
+ORDER BY created_at
+LIMIT ? OFFSET ?;
+
+-- name: SetUserRole :exec
+UPDATE users
+SET role = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: DisableUser :exec
+UPDATE users
+SET disabled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: EnableUser :exec
+UPDATE users
+SET disabled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = sqlc.arg('step'), updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg('id') AND totp_last_step < sqlc.arg('step');
+
+-- name: GetUsersWithTOTPSecret :many
+SELECT * FROM users
+WHERE totp_secret IS NOT NULL;
+
+-- name: ReplaceUserTOTPSecret :execrows
+UPDATE users
+SET totp_secret = sqlc.arg('new_secret')
+WHERE id = sqlc.arg('id') AND totp_secret = sqlc.arg('old_secret');
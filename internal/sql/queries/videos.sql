@@ -1,17 +1,26 @@
 -- name: CreateVideo :one
-INSERT INTO videos(id, created_at, updated_at, title, description, user_id)
+INSERT INTO videos(id, created_at, updated_at, title, description, user_id, organization_id)
 VALUES (
     ?,
     CURRENT_TIMESTAMP,
     CURRENT_TIMESTAMP,
     ?,
     ?,
+    ?,
     ?
 ) RETURNING *;
 
 -- name: GetVideosByUser :many
 SELECT * FROM videos WHERE user_id = ?;
 
+-- name: GetVideosByOrganization :many
+SELECT * FROM videos WHERE organization_id = ?;
+
+-- name: GetVideosByMember :many
+SELECT videos.* FROM videos
+JOIN organization_members ON organization_members.organization_id = videos.organization_id
+WHERE organization_members.user_id = ?;
+
 -- name: GetVideo :one
 SELECT * FROM videos WHERE id = ?;
 
@@ -29,7 +38,16 @@ RETURNING * ;
 
 -- name: DeleteVideo :exec
 DELETE FROM videos 
-WHERE id = ? AND user_id = ?;
+WHERE id = ?;
 
 -- name: DeleteAllVideos :exec
 DELETE FROM videos;
+
+-- name: DeleteVideosByOrganization :exec
+DELETE FROM videos
+WHERE organization_id = ?;
+
+-- name: ListVideos :many
+SELECT * FROM videos
+ORDER BY created_at DESC
+LIMIT ? OFFSET ?;
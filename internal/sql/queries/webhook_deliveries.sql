@@ -0,0 +1,67 @@
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (id, created_at, updated_at, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at)
+VALUES (
+    ?,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    ?,
+    ?,
+    ?,
+    ?,
+    'pending',
+    0,
+    ?
+)
+ON CONFLICT (subscription_id, event_id) DO NOTHING;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = ? AND subscription_id = ?;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = ?
+ORDER BY created_at DESC
+LIMIT ? OFFSET ?;
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg('lease_until'), updated_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= sqlc.arg('now')
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg('limit')
+)
+RETURNING *;
+
+-- name: CountPendingWebhookDeliveries :one
+SELECT COUNT(*) FROM webhook_deliveries
+WHERE status = 'pending';
+
+-- name: RecordWebhookDeliveryAttempt :exec
+UPDATE webhook_deliveries
+SET status = ?, attempts = attempts + 1, next_attempt_at = ?, last_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND subscription_id = ?
+RETURNING *;
+
+-- name: CreateWebhookDeliveryAttempt :exec
+INSERT INTO webhook_delivery_attempts (delivery_id, created_at, response_status, response_body, error, duration_ms)
+VALUES (
+    ?,
+    CURRENT_TIMESTAMP,
+    ?,
+    ?,
+    ?,
+    ?
+);
+
+-- name: GetWebhookDeliveryAttempts :many
+SELECT * FROM webhook_delivery_attempts
+WHERE delivery_id = ?
+ORDER BY id;
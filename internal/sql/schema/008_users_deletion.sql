@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN deletion_scheduled_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN deletion_scheduled_at;
\ No newline at end of file
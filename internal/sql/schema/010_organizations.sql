@@ -0,0 +1,82 @@
+-- +goose Up
+CREATE TABLE organizations(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE organization_members(
+    organization_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (organization_id, user_id),
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE organization_invitations(
+    token_hash TEXT PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    invited_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Every existing user gets a personal organization sharing their id, which
+-- takes over the videos they own.
+INSERT INTO organizations (id, created_at, updated_at, name)
+SELECT id, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, email FROM users;
+
+INSERT INTO organization_members (organization_id, user_id, role, created_at, updated_at)
+SELECT id, id, 'owner', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM users;
+
+CREATE TABLE videos_with_organization(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    thumbnail_url TEXT NOT NULL DEFAULT '',
+    video_url TEXT NOT NULL DEFAULT '',
+    title TEXT UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    user_id TEXT NOT NULL,
+    organization_id TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+INSERT INTO videos_with_organization
+SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, user_id FROM videos;
+
+DROP TABLE videos;
+ALTER TABLE videos_with_organization RENAME TO videos;
+
+-- +goose Down
+CREATE TABLE videos_without_organization(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    thumbnail_url TEXT NOT NULL DEFAULT '',
+    video_url TEXT NOT NULL DEFAULT '',
+    title TEXT UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    user_id TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO videos_without_organization
+SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id FROM videos;
+
+DROP TABLE videos;
+ALTER TABLE videos_without_organization RENAME TO videos;
+
+DROP TABLE organization_invitations;
+DROP TABLE organization_members;
+DROP TABLE organizations;
\ No newline at end of file
@@ -0,0 +1,35 @@
+-- +goose Up
+-- Audit events outlive the users and videos they mention, so there are no
+-- foreign keys here.
+CREATE TABLE audit_events(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at TIMESTAMP NOT NULL,
+    action TEXT NOT NULL,
+    actor_id TEXT,
+    ip TEXT NOT NULL DEFAULT '',
+    target_type TEXT NOT NULL DEFAULT '',
+    target_id TEXT NOT NULL DEFAULT '',
+    diff TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX audit_events_actor_id_idx ON audit_events(actor_id);
+CREATE INDEX audit_events_target_idx ON audit_events(target_type, target_id);
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER audit_events_no_delete;
+DROP TRIGGER audit_events_no_update;
+DROP TABLE audit_events;
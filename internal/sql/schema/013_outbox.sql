@@ -0,0 +1,30 @@
+-- +goose Up
+-- The outbox holds domain events written in the same transaction as the
+-- change they describe. Unpublished ones are picked up once next_attempt_at is
+-- due, the same way webhook deliveries are, and published ones are kept for a
+-- while before they're cleaned up. Like audit events, they outlive the users
+-- and videos they mention.
+CREATE TABLE outbox_events(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    event_type TEXT NOT NULL,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    published_at TIMESTAMP
+);
+
+CREATE INDEX outbox_events_due_idx ON outbox_events(published_at, next_attempt_at);
+
+-- Events can be published more than once, webhooks only queue one delivery of
+-- each event per subscription.
+CREATE UNIQUE INDEX webhook_deliveries_event_idx ON webhook_deliveries(subscription_id, event_id);
+
+-- +goose Down
+DROP INDEX webhook_deliveries_event_idx;
+DROP TABLE outbox_events;
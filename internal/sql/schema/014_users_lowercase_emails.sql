@@ -0,0 +1,15 @@
+-- +goose Up
+-- Emails are looked up lower-cased since signup started normalizing them.
+-- Accounts whose address only differs by case from another one are left
+-- alone: lower-casing them would break the unique constraint, and which of
+-- them keeps the address is for an operator to decide.
+UPDATE users SET email = lower(email)
+WHERE email <> lower(email)
+AND NOT EXISTS (
+    SELECT 1 FROM users AS other
+    WHERE other.id <> users.id AND lower(other.email) = lower(users.email)
+);
+
+-- +goose Down
+-- The original case is gone, lower-cased addresses work either way.
+SELECT 1;
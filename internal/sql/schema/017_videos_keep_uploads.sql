@@ -0,0 +1,45 @@
+-- +goose Up
+-- Videos belong to their organization. Deleting the account that uploaded one
+-- leaves it in a shared library without an uploader.
+CREATE TABLE videos_with_optional_user(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    thumbnail_url TEXT NOT NULL DEFAULT '',
+    video_url TEXT NOT NULL DEFAULT '',
+    title TEXT UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    user_id TEXT,
+    organization_id TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+INSERT INTO videos_with_optional_user
+SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, organization_id FROM videos;
+
+DROP TABLE videos;
+ALTER TABLE videos_with_optional_user RENAME TO videos;
+
+-- +goose Down
+CREATE TABLE videos_with_user(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    thumbnail_url TEXT NOT NULL DEFAULT '',
+    video_url TEXT NOT NULL DEFAULT '',
+    title TEXT UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    user_id TEXT NOT NULL,
+    organization_id TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+-- Videos without an uploader can't be kept.
+INSERT INTO videos_with_user
+SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, organization_id FROM videos
+WHERE user_id IS NOT NULL;
+
+DROP TABLE videos;
+ALTER TABLE videos_with_user RENAME TO videos;
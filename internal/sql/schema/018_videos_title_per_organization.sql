@@ -0,0 +1,44 @@
+-- +goose Up
+-- Titles only have to be unique within the library of an organization.
+CREATE TABLE videos_with_organization_titles(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    thumbnail_url TEXT NOT NULL DEFAULT '',
+    video_url TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    user_id TEXT,
+    organization_id TEXT NOT NULL,
+    UNIQUE (organization_id, title),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+INSERT INTO videos_with_organization_titles
+SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, organization_id FROM videos;
+
+DROP TABLE videos;
+ALTER TABLE videos_with_organization_titles RENAME TO videos;
+
+-- +goose Down
+-- Fails while two organizations use the same title, rename one of them first.
+CREATE TABLE videos_with_unique_titles(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    thumbnail_url TEXT NOT NULL DEFAULT '',
+    video_url TEXT NOT NULL DEFAULT '',
+    title TEXT UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    user_id TEXT,
+    organization_id TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+INSERT INTO videos_with_unique_titles
+SELECT id, created_at, updated_at, thumbnail_url, video_url, title, description, user_id, organization_id FROM videos;
+
+DROP TABLE videos;
+ALTER TABLE videos_with_unique_titles RENAME TO videos;
@@ -0,0 +1,8 @@
+// Package schema embeds the goose migrations sqlc generates the database
+// package from, so the binary can bring any database up to date on its own.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,142 @@
+// Package sqlite opens the application database with the pragmas the schema
+// relies on: enforced foreign keys, WAL journaling and a busy timeout.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	DefaultBusyTimeout  time.Duration = time.Second * 5
+	DefaultMaxReadConns int           = 8
+	ReadConnMaxIdleTime time.Duration = time.Minute * 5
+)
+
+type Options struct {
+	// BusyTimeout is how long a connection waits on a lock held by another
+	// one before failing with "database is locked".
+	BusyTimeout  time.Duration
+	MaxReadConns int
+}
+
+// DB routes statements between two pools on the same file. SQLite only allows
+// one writer at a time, so writes are serialized on a single connection and
+// queue in Go, while reads run concurrently next to it thanks to WAL.
+type DB struct {
+	Reader *sql.DB
+	Writer *sql.DB
+}
+
+func dsn(path string, options Options, extra url.Values) string {
+	params := url.Values{}
+	params.Set("_foreign_keys", "on")
+	params.Set("_journal_mode", "WAL")
+	params.Set("_synchronous", "NORMAL")
+	params.Set("_busy_timeout", strconv.FormatInt(options.BusyTimeout.Milliseconds(), 10))
+	for key, values := range extra {
+		params[key] = values
+	}
+	return "file:" + path + "?" + params.Encode()
+}
+
+// Open opens the database at path, creating it if needed.
+func Open(path string, options Options) (*DB, error) {
+	if options.BusyTimeout <= 0 {
+		options.BusyTimeout = DefaultBusyTimeout
+	}
+	if options.MaxReadConns <= 0 {
+		options.MaxReadConns = DefaultMaxReadConns
+	}
+	// Transactions take the write lock upfront: upgrading a read lock later
+	// fails right away with SQLITE_BUSY instead of waiting for the timeout.
+	writer, err := sql.Open("sqlite3", dsn(path, options, url.Values{"_txlock": {"immediate"}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database writer: %w", err)
+	}
+	writer.SetMaxOpenConns(1)
+	writer.SetMaxIdleConns(1)
+	writer.SetConnMaxLifetime(0)
+	// The writer creates the file and switches it to WAL before read-only
+	// connections open it.
+	if err := writer.Ping(); err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("failed to open database writer: %w", err)
+	}
+	reader, err := sql.Open("sqlite3", dsn(path, options, url.Values{"mode": {"ro"}}))
+	if err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("failed to open database reader: %w", err)
+	}
+	reader.SetMaxOpenConns(options.MaxReadConns)
+	reader.SetMaxIdleConns(options.MaxReadConns)
+	reader.SetConnMaxIdleTime(ReadConnMaxIdleTime)
+	return &DB{Reader: reader, Writer: writer}, nil
+}
+
+func (db *DB) Close() error {
+	return errors.Join(db.Reader.Close(), db.Writer.Close())
+}
+
+func (db *DB) PingContext(ctx context.Context) error {
+	if err := db.Writer.PingContext(ctx); err != nil {
+		return err
+	}
+	return db.Reader.PingContext(ctx)
+}
+
+// isRead reports whether query only reads. sqlc prefixes queries with a
+// "-- name:" comment, and writes returning rows still go to the writer.
+func isRead(query string) bool {
+	query = strings.TrimSpace(query)
+	for strings.HasPrefix(query, "--") {
+		_, rest, _ := strings.Cut(query, "\n")
+		query = strings.TrimSpace(rest)
+	}
+	keyword, _, _ := strings.Cut(query, " ")
+	keyword = strings.ToUpper(strings.TrimSpace(keyword))
+	return keyword == "SELECT" || keyword == "WITH" && !strings.Contains(strings.ToUpper(query), "RETURNING")
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.Writer.ExecContext(ctx, query, args...)
+}
+
+func (db *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if isRead(query) {
+		return db.Reader.PrepareContext(ctx, query)
+	}
+	return db.Writer.PrepareContext(ctx, query)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if isRead(query) {
+		return db.Reader.QueryContext(ctx, query, args...)
+	}
+	return db.Writer.QueryContext(ctx, query, args...)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if isRead(query) {
+		return db.Reader.QueryRowContext(ctx, query, args...)
+	}
+	return db.Writer.QueryRowContext(ctx, query, args...)
+}
+
+// IsUniqueViolation reports whether err is a write rejected by a UNIQUE or
+// PRIMARY KEY constraint.
+func IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+}
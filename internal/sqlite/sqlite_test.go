@@ -0,0 +1,182 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charlesaraya/video-manager-go/internal/database"
+	"github.com/charlesaraya/video-manager-go/internal/migrations"
+	"github.com/charlesaraya/video-manager-go/internal/sqlite"
+)
+
+// openTestDB opens a migrated database in a temporary directory.
+func openTestDB(t *testing.T) *database.Queries {
+	t.Helper()
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"), sqlite.Options{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := migrations.Up(context.Background(), migrations.DriverSQLite, db.Writer); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return database.New(db)
+}
+
+func createUser(t *testing.T, q *database.Queries, id string) database.User {
+	t.Helper()
+	user, err := q.CreateUser(context.Background(), database.CreateUserParams{
+		ID:       id,
+		Email:    id + "@example.com",
+		Password: "hash-" + id,
+	})
+	if err != nil {
+		t.Fatalf("failed to create user %s: %v", id, err)
+	}
+	return user
+}
+
+func createOrganization(t *testing.T, q *database.Queries, id string, members map[string]string) database.Organization {
+	t.Helper()
+	ctx := context.Background()
+	org, err := q.CreateOrganization(ctx, database.CreateOrganizationParams{ID: id, Name: id})
+	if err != nil {
+		t.Fatalf("failed to create organization %s: %v", id, err)
+	}
+	for userID, role := range members {
+		err := q.AddOrganizationMember(ctx, database.AddOrganizationMemberParams{
+			OrganizationID: org.ID,
+			UserID:         userID,
+			Role:           role,
+		})
+		if err != nil {
+			t.Fatalf("failed to add %s to organization %s: %v", userID, id, err)
+		}
+	}
+	return org
+}
+
+func createVideo(t *testing.T, q *database.Queries, id, userID, orgID string) database.Video {
+	t.Helper()
+	video, err := q.CreateVideo(context.Background(), database.CreateVideoParams{
+		ID:             id,
+		Title:          id,
+		UserID:         &userID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		t.Fatalf("failed to create video %s: %v", id, err)
+	}
+	return video
+}
+
+// assertGone fails unless err is what getting a removed row returns.
+func assertGone(t *testing.T, what string, err error) {
+	t.Helper()
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("%s: got error %v, want %v", what, err, sql.ErrNoRows)
+	}
+}
+
+func TestForeignKeysAreEnforced(t *testing.T) {
+	q := openTestDB(t)
+	_, err := q.CreateRefreshToken(context.Background(), database.CreateRefreshTokenParams{
+		Token:     "token",
+		UserID:    "missing",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err == nil {
+		t.Fatal("created a refresh token of a missing user")
+	}
+}
+
+func TestDeleteUserCascades(t *testing.T) {
+	ctx := context.Background()
+	q := openTestDB(t)
+	user := createUser(t, q, "alice")
+	owner := createUser(t, q, "bob")
+	shared := createOrganization(t, q, "shared", map[string]string{owner.ID: "owner", user.ID: "editor"})
+	video := createVideo(t, q, "video", user.ID, shared.ID)
+	if _, err := q.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		Token:     "token",
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("failed to create refresh token: %v", err)
+	}
+	if _, err := q.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: "reset",
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("failed to create password reset token: %v", err)
+	}
+	if _, err := q.CreateWebhookSubscription(ctx, database.CreateWebhookSubscriptionParams{
+		ID:     "webhook",
+		UserID: user.ID,
+		Url:    "https://example.com/hook",
+		Secret: "secret",
+	}); err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+
+	if err := q.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+
+	_, err := q.GetRefreshToken(ctx, "token")
+	assertGone(t, "refresh token", err)
+	_, err = q.GetPasswordResetToken(ctx, "reset")
+	assertGone(t, "password reset token", err)
+	_, err = q.GetWebhookSubscriptionByID(ctx, "webhook")
+	assertGone(t, "webhook", err)
+	_, err = q.GetOrganizationMember(ctx, database.GetOrganizationMemberParams{OrganizationID: shared.ID, UserID: user.ID})
+	assertGone(t, "membership", err)
+	// The organization keeps the video, without its uploader.
+	kept, err := q.GetVideo(ctx, video.ID)
+	if err != nil {
+		t.Fatalf("failed to get video of deleted user: %v", err)
+	}
+	if kept.UserID != nil {
+		t.Errorf("video user_id = %q, want null", *kept.UserID)
+	}
+	if _, err := q.GetOrganizationMember(ctx, database.GetOrganizationMemberParams{OrganizationID: shared.ID, UserID: owner.ID}); err != nil {
+		t.Errorf("failed to get membership of another user: %v", err)
+	}
+}
+
+func TestDeleteOrganizationCascades(t *testing.T) {
+	ctx := context.Background()
+	q := openTestDB(t)
+	user := createUser(t, q, "alice")
+	org := createOrganization(t, q, "team", map[string]string{user.ID: "owner"})
+	video := createVideo(t, q, "video", user.ID, org.ID)
+	if err := q.CreateOrganizationInvitation(ctx, database.CreateOrganizationInvitationParams{
+		TokenHash:      "invitation",
+		OrganizationID: org.ID,
+		Email:          "carol@example.com",
+		Role:           "viewer",
+		InvitedBy:      user.ID,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("failed to create invitation: %v", err)
+	}
+
+	if err := q.DeleteOrganization(ctx, org.ID); err != nil {
+		t.Fatalf("failed to delete organization: %v", err)
+	}
+
+	_, err := q.GetOrganizationMember(ctx, database.GetOrganizationMemberParams{OrganizationID: org.ID, UserID: user.ID})
+	assertGone(t, "membership", err)
+	_, err = q.GetOrganizationInvitation(ctx, "invitation")
+	assertGone(t, "invitation", err)
+	_, err = q.GetVideo(ctx, video.ID)
+	assertGone(t, "video", err)
+	if _, err := q.GetUserByID(ctx, user.ID); err != nil {
+		t.Errorf("failed to get member of deleted organization: %v", err)
+	}
+}
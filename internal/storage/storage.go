@@ -0,0 +1,34 @@
+// Package storage keeps uploaded videos in a blob store: an S3 bucket served
+// through CloudFront, or a local directory served by the app itself.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid object key")
+
+// Blobs stores objects under slash separated keys, e.g. "landscape/abc.mp4".
+type Blobs interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// Check reports whether the store is reachable.
+	Check(ctx context.Context) error
+	// URL is where clients download the object stored at key.
+	URL(key string) string
+	// Key maps a URL returned by URL back to its key.
+	Key(url string) (string, bool)
+}
+
+// keyFromURL strips baseURL and the slash after it from url.
+func keyFromURL(baseURL, url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, strings.TrimSuffix(baseURL, "/")+"/")
+	if !ok || key == "" {
+		return "", false
+	}
+	return key, true
+}
@@ -1,45 +1,361 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/charlesaraya/video-manager-go/internal/api"
+	"github.com/charlesaraya/video-manager-go/internal/config"
+	"github.com/charlesaraya/video-manager-go/internal/metrics"
+	"github.com/charlesaraya/video-manager-go/internal/migrations"
+	"github.com/charlesaraya/video-manager-go/internal/storage"
+	"github.com/charlesaraya/video-manager-go/internal/tracing"
 )
 
 func main() {
-	cfg, err := api.LoadConfig()
+	// Migrations run before LoadConfig, which refuses to start on a database
+	// that isn't up to date.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "create-admin" {
+		createAdmin(os.Args[2:])
+		return
+	}
+	settings := loadSettings("video-manager", os.Args[1:])
+	if err := settings.Validate(); err != nil {
+		fatalSettings(err)
+	}
+	cfg, err := api.LoadConfig(settings)
+	if err != nil {
+		fatal("error loading api config", err)
+	}
+	slog.SetDefault(cfg.Logger)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingExporter, cfg.ServiceName)
 	if err != nil {
-		log.Fatal(fmt.Errorf("error loading api config: %w", err))
+		fatal("error setting up tracing", err)
 	}
+
 	// 1. Create Server
+	// Requests don't inherit ctx: in-flight uploads keep going while the server
+	// drains, and only get cancelled once the drain timeout is over.
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 	mux := http.NewServeMux()
 	server := &http.Server{
-		Handler: mux,
-		Addr:    ":" + cfg.Port,
+		Handler:           api.TracingMiddleware(api.RequestIDMiddleware(api.AccessLogMiddleware(api.MetricsMiddleware(api.RouteSpanMiddleware(mux))))),
+		Addr:              ":" + cfg.Port,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(cfg.Logger.Handler(), slog.LevelWarn),
+		BaseContext: func(net.Listener) context.Context {
+			return requestsCtx
+		},
 	}
 	// 2. Set up handlers
 	mux.Handle("/", api.AppHandler(cfg))
+	mux.HandleFunc("GET "+api.PasswordResetLinkPath, api.AppPageHandler(cfg))
+	mux.HandleFunc("GET "+api.VerifyEmailLinkPath, api.AppPageHandler(cfg))
+	mux.HandleFunc("GET "+api.InvitationLinkPath, api.AppPageHandler(cfg))
 
 	assetsHandler := http.StripPrefix("/assets", http.FileServer(http.Dir(cfg.AssetsDirPath)))
 	mux.Handle(cfg.AssetsBrowserURL, api.CacheMiddleware(assetsHandler))
 
-	mux.HandleFunc("POST /api/users", api.CreateUserHandler(cfg))
-	mux.HandleFunc("POST /api/login", api.LoginHandler(cfg))
-	mux.HandleFunc("POST /api/refresh", api.RefreshTokenHandler(cfg))
-	mux.HandleFunc("POST /api/revoke", api.RevokeTokenHandler(cfg))
+	if local, ok := cfg.Storage.(*storage.Local); ok {
+		mediaHandler := http.StripPrefix(strings.TrimSuffix(api.LocalStoragePath, "/"), http.FileServer(http.Dir(local.Dir())))
+		mux.Handle("GET "+api.LocalStoragePath, mediaHandler)
+	}
+
+	mux.HandleFunc("GET /.well-known/jwks.json", api.JWKSHandler(cfg))
+	mux.HandleFunc("GET /api/openapi.json", api.OpenAPIHandler)
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", api.HealthzHandler(cfg))
+	mux.HandleFunc("GET /readyz", api.ReadyzHandler(cfg))
 
-	mux.HandleFunc("GET /api/videos", api.AuthMiddleware(cfg, api.GetAllVideosHandler))
-	mux.HandleFunc("GET /api/videos/{videoID}", api.GetVideoHandler(cfg))
+	// Rate limited route groups
+	authLimit := func(next http.HandlerFunc) http.HandlerFunc {
+		return api.RateLimitMiddleware(cfg, cfg.AuthLimiter, next)
+	}
+	uploadLimit := func(next http.HandlerFunc) http.HandlerFunc {
+		return api.RateLimitMiddleware(cfg, cfg.UploadLimiter, next)
+	}
+	readLimit := func(next http.HandlerFunc) http.HandlerFunc {
+		return api.RateLimitMiddleware(cfg, cfg.ReadLimiter, next)
+	}
+
+	mux.HandleFunc("POST /api/users", authLimit(api.CreateUserHandler(cfg)))
+	mux.HandleFunc("POST /api/login", authLimit(api.LoginHandler(cfg)))
+	mux.HandleFunc("POST /api/login/mfa", authLimit(api.LoginMFAHandler(cfg)))
+	mux.HandleFunc("POST /api/refresh", authLimit(api.RefreshTokenHandler(cfg)))
+	mux.HandleFunc("POST /api/revoke", authLimit(api.RevokeTokenHandler(cfg)))
+	mux.HandleFunc("POST /api/password/forgot", authLimit(api.ForgotPasswordHandler(cfg)))
+	mux.HandleFunc("POST /api/password/reset", authLimit(api.ResetPasswordHandler(cfg)))
+	mux.HandleFunc("POST /api/email/verify", authLimit(api.VerifyEmailHandler(cfg)))
+	mux.HandleFunc("POST /api/email/verify/resend", authLimit(api.AuthMiddleware(cfg, api.ResendVerificationEmailHandler)))
+	mux.HandleFunc("GET /api/users/me", readLimit(api.AuthMiddleware(cfg, api.GetCurrentUserHandler)))
+	mux.HandleFunc("PATCH /api/users/me", authLimit(api.AuthMiddleware(cfg, api.UpdateCurrentUserHandler)))
+	mux.HandleFunc("PUT /api/users/me/password", authLimit(api.AuthMiddleware(cfg, api.ChangePasswordHandler)))
+	mux.HandleFunc("GET /api/users/me/export", readLimit(api.AuthMiddleware(cfg, api.ExportUserDataHandler)))
+	mux.HandleFunc("DELETE /api/users/me", authLimit(api.AuthMiddleware(cfg, api.DeleteCurrentUserHandler)))
+	mux.HandleFunc("POST /api/users/me/restore", authLimit(api.AuthMiddleware(cfg, api.RestoreCurrentUserHandler)))
+	mux.HandleFunc("POST /api/mfa/totp/enroll", authLimit(api.AuthMiddleware(cfg, api.EnrollTOTPHandler)))
+	mux.HandleFunc("POST /api/mfa/totp/confirm", authLimit(api.AuthMiddleware(cfg, api.ConfirmTOTPHandler)))
+	mux.HandleFunc("DELETE /api/mfa/totp", authLimit(api.AuthMiddleware(cfg, api.DisableTOTPHandler)))
+
+	if cfg.OIDC != nil {
+		mux.HandleFunc("GET /auth/oidc/login", authLimit(api.OIDCLoginHandler(cfg)))
+		mux.HandleFunc("GET "+api.OIDCCallbackPath, authLimit(api.OIDCCallbackHandler(cfg)))
+	}
+
+	mux.HandleFunc("GET /api/videos", readLimit(api.AuthMiddleware(cfg, api.GetAllVideosHandler)))
+	mux.HandleFunc("GET /api/videos/{videoID}", readLimit(api.AuthMiddleware(cfg, api.GetVideoHandler)))
 	mux.HandleFunc("POST /api/videos", api.AuthMiddleware(cfg, api.AddVideoHandler))
 	mux.HandleFunc("DELETE /api/videos/{videoID}", api.AuthMiddleware(cfg, api.DeleteVideoHandler))
-	mux.HandleFunc("UPDATE /api/videos/{videoID}", api.AuthMiddleware(cfg, api.UploadThumbnailHandler))
-	mux.HandleFunc("POST /api/video_upload/{videoID}", api.AuthMiddleware(cfg, api.UploadVideosHandler))
+	mux.HandleFunc("UPDATE /api/videos/{videoID}", uploadLimit(api.AuthMiddleware(cfg, api.RequireVerifiedEmail(api.UploadThumbnailHandler))))
+	mux.HandleFunc("POST /api/thumbnail_upload/{videoID}", uploadLimit(api.AuthMiddleware(cfg, api.RequireVerifiedEmail(api.UploadThumbnailHandler))))
+	mux.HandleFunc("POST /api/video_upload/{videoID}", uploadLimit(api.AuthMiddleware(cfg, api.RequireVerifiedEmail(api.UploadVideosHandler))))
+
+	mux.HandleFunc("POST /api/organizations", api.AuthMiddleware(cfg, api.CreateOrganizationHandler))
+	mux.HandleFunc("GET /api/organizations", readLimit(api.AuthMiddleware(cfg, api.GetOrganizationsHandler)))
+	mux.HandleFunc("GET /api/organizations/{organizationID}/members", readLimit(api.AuthMiddleware(cfg, api.GetOrganizationMembersHandler)))
+	mux.HandleFunc("PUT /api/organizations/{organizationID}/members/{userID}", api.AuthMiddleware(cfg, api.UpdateOrganizationMemberHandler))
+	mux.HandleFunc("DELETE /api/organizations/{organizationID}/members/{userID}", api.AuthMiddleware(cfg, api.RemoveOrganizationMemberHandler))
+	mux.HandleFunc("POST /api/organizations/{organizationID}/invitations", authLimit(api.AuthMiddleware(cfg, api.InviteOrganizationMemberHandler)))
+	mux.HandleFunc("POST /api/invitations/accept", authLimit(api.AuthMiddleware(cfg, api.AcceptInvitationHandler)))
 
-	mux.HandleFunc("POST /admin/reset", api.ResetHandler(cfg))
+	mux.HandleFunc("POST /api/webhooks", api.AuthMiddleware(cfg, api.CreateWebhookHandler))
+	mux.HandleFunc("GET /api/webhooks", readLimit(api.AuthMiddleware(cfg, api.GetWebhooksHandler)))
+	mux.HandleFunc("GET /api/webhooks/{webhookID}", readLimit(api.AuthMiddleware(cfg, api.GetWebhookHandler)))
+	mux.HandleFunc("PATCH /api/webhooks/{webhookID}", api.AuthMiddleware(cfg, api.UpdateWebhookHandler))
+	mux.HandleFunc("DELETE /api/webhooks/{webhookID}", api.AuthMiddleware(cfg, api.DeleteWebhookHandler))
+	mux.HandleFunc("GET /api/webhooks/{webhookID}/deliveries", readLimit(api.AuthMiddleware(cfg, api.GetWebhookDeliveriesHandler)))
+	mux.HandleFunc("GET /api/webhooks/{webhookID}/deliveries/{deliveryID}", readLimit(api.AuthMiddleware(cfg, api.GetWebhookDeliveryHandler)))
+	mux.HandleFunc("POST /api/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", api.AuthMiddleware(cfg, api.RedeliverWebhookHandler))
+
+	mux.HandleFunc("GET /admin/users", api.AdminMiddleware(cfg, api.AdminListUsersHandler))
+	mux.HandleFunc("POST /admin/users/{userID}/disable", api.AdminMiddleware(cfg, api.AdminDisableUserHandler))
+	mux.HandleFunc("POST /admin/users/{userID}/enable", api.AdminMiddleware(cfg, api.AdminEnableUserHandler))
+	mux.HandleFunc("DELETE /admin/users/{userID}", api.AdminMiddleware(cfg, api.AdminDeleteUserHandler))
+	mux.HandleFunc("POST /admin/users/{userID}/impersonate", api.AdminMiddleware(cfg, api.AdminImpersonateUserHandler))
+	mux.HandleFunc("GET /admin/videos", api.AdminMiddleware(cfg, api.AdminListVideosHandler))
+	mux.HandleFunc("POST /admin/videos/{videoID}/takedown", api.AdminMiddleware(cfg, api.AdminTakedownVideoHandler))
+	mux.HandleFunc("GET /admin/audit", api.AdminMiddleware(cfg, api.AdminListAuditEventsHandler))
+	mux.HandleFunc("GET /admin/audit/export", api.AdminMiddleware(cfg, api.AdminExportAuditEventsHandler))
+	mux.HandleFunc("POST /admin/reset", api.AdminMiddleware(cfg, api.ResetHandler))
+
+	// 3. Start background jobs
+	go api.RunAccountPurger(ctx, cfg)
+	go api.RunWebhookDispatcher(ctx, cfg)
+	go api.RunOutboxDispatcher(ctx, cfg)
+
+	// 4. Start server
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	slog.Info("serving", "url", fmt.Sprintf("http://localhost:%s/", cfg.Port))
+
+	select {
+	case err := <-serverErr:
+		fatal("error serving", err)
+	case <-ctx.Done():
+	}
+	// A second signal kills the process right away.
+	stop()
+
+	// 5. Drain in-flight requests
+	// Failing readiness first lets load balancers stop sending new traffic
+	// while the listener is still open.
+	cfg.ShuttingDown.Store(true)
+	if cfg.ReadinessDrainDelay > 0 {
+		slog.Info("failing readiness before draining", "delay", cfg.ReadinessDrainDelay)
+		time.Sleep(cfg.ReadinessDrainDelay)
+	}
+	slog.Info("shutting down, draining in-flight requests", "timeout", cfg.ShutdownTimeout)
+	if err := shutdown(server, cfg.ShutdownTimeout); err != nil {
+		slog.Warn("drain timed out, cancelling in-flight requests", "error", err)
+		// Cancelled handlers kill their ffmpeg/ffprobe subprocesses and remove
+		// their temp files on the way out, give them a moment to do so.
+		cancelRequests()
+		if err := shutdown(server, ShutdownCleanupTimeout); err != nil {
+			server.Close()
+		}
+	}
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("error serving", "error", err)
+	}
+	if !waitBackground(cfg, ShutdownCleanupTimeout) {
+		slog.Warn("background tasks still running, exiting anyway")
+	}
+	// Spans of the last requests are still buffered.
+	if err := flushTraces(shutdownTracing, ShutdownCleanupTimeout); err != nil {
+		slog.Warn("failed to flush traces", "error", err)
+	}
+	slog.Info("server stopped")
+}
+
+// ShutdownCleanupTimeout bounds how long cancelled requests get to clean up
+// once the drain timeout is over.
+const ShutdownCleanupTimeout = 5 * time.Second
+
+func shutdown(server *http.Server, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
+
+// waitBackground reports whether the background tasks of cfg finished
+// within timeout.
+func waitBackground(cfg *api.Config, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		cfg.Background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func flushTraces(shutdownTracing func(context.Context) error, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return shutdownTracing(ctx)
+}
+
+// loadSettings loads the layered settings, the remaining arguments after the
+// flags are an error.
+func loadSettings(name string, args []string) *config.Config {
+	settings, rest, err := config.Load(name, args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err == nil && len(rest) > 0 {
+		err = fmt.Errorf("unexpected arguments %v", rest)
+	}
+	if err != nil {
+		fatal("error loading configuration", err)
+	}
+	return settings
+}
+
+// createAdmin bootstraps the first admin account:
+//
+//	video-manager create-admin -email admin@example.com -password secret
+//
+// The settings come from the config file and the environment.
+func createAdmin(args []string) {
+	flags := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	email := flags.String("email", "", "email of the account to promote")
+	password := flags.String("password", os.Getenv("ADMIN_PASSWORD"), "password used if the account has to be created")
+	flags.Parse(args)
+	settings := loadSettings("video-manager", nil)
+	if err := settings.Validate(); err != nil {
+		fatalSettings(err)
+	}
+	cfg, err := api.LoadConfig(settings)
+	if err != nil {
+		fatal("error loading api config", err)
+	}
+	slog.SetDefault(cfg.Logger)
+	if err := api.BootstrapAdmin(context.Background(), cfg, *email, *password); err != nil {
+		fatal("error creating admin", err)
+	}
+	slog.Info("admin created", "email", *email)
+}
+
+// migrate manages the database schema without starting the server:
+//
+//	video-manager migrate [flags] up|down|status
+//
+// Only the database settings are needed.
+func migrate(args []string) {
+	settings, rest, err := config.Load("video-manager migrate", args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fatal("error loading configuration", err)
+	}
+	if err := settings.ValidateDatabase(); err != nil {
+		fatalSettings(err)
+	}
+	db, err := api.OpenDatabase(settings.Database)
+	if err != nil {
+		fatal("error opening database", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	command := ""
+	if len(rest) == 1 {
+		command = rest[0]
+	}
+	switch command {
+	case "up":
+		applied, err := migrations.Up(ctx, db.Driver, db.Primary)
+		for _, version := range applied {
+			fmt.Printf("applied %d\n", version)
+		}
+		if err != nil {
+			fatal("error migrating database", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		version, err := migrations.Down(ctx, db.Driver, db.Primary)
+		if err != nil {
+			fatal("error migrating database", err)
+		}
+		fmt.Printf("rolled back %d\n", version)
+	case "status":
+		statuses, err := migrations.List(ctx, db.Driver, db.Primary)
+		if err != nil {
+			fatal("error reading migration status", err)
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+		for _, status := range statuses {
+			appliedAt := "-"
+			if !status.AppliedAt.IsZero() {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", status.Source.Version, status.State, appliedAt, filepath.Base(status.Source.Path))
+		}
+		writer.Flush()
+	default:
+		fatal("error migrating database", fmt.Errorf("unknown migrate command %q (up, down or status)", command))
+	}
+}
+
+// fatalSettings lists every invalid setting, one per line, before exiting.
+func fatalSettings(err error) {
+	fmt.Fprintln(os.Stderr, "invalid configuration:")
+	for _, line := range strings.Split(err.Error(), "\n") {
+		fmt.Fprintln(os.Stderr, "  "+line)
+	}
+	os.Exit(1)
+}
 
-	// 3. Start server
-	log.Printf("Serving: http://localhost:%s/\n", cfg.Port)
-	server.ListenAndServe()
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// MFARequiredError is returned by Login for accounts with two-factor
+// authentication. Finish the login with LoginMFA.
+type MFARequiredError struct {
+	MFAToken string
+}
+
+func (e *MFARequiredError) Error() string {
+	return "client: two-factor authentication required"
+}
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Register creates an account. It doesn't log in.
+func (c *Client) Register(ctx context.Context, email, password string) (*User, error) {
+	user := &User{}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   credentials{Email: email, Password: password},
+	}, user)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Login starts a session. Accounts with two-factor authentication get a
+// *MFARequiredError instead.
+func (c *Client) Login(ctx context.Context, email, password string) (*Session, error) {
+	var raw json.RawMessage
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/login",
+		body:   credentials{Email: email, Password: password},
+	}, &raw)
+	if err != nil {
+		return nil, err
+	}
+	var challenge struct {
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+	}
+	if err := json.Unmarshal(raw, &challenge); err == nil && challenge.MFARequired {
+		return nil, &MFARequiredError{MFAToken: challenge.MFAToken}
+	}
+	return c.startSession(raw)
+}
+
+// LoginMFA finishes a login with an authentication code, or a recovery code
+// when recovery is true.
+func (c *Client) LoginMFA(ctx context.Context, mfaToken, code string, recovery bool) (*Session, error) {
+	body := map[string]string{"mfa_token": mfaToken}
+	if recovery {
+		body["recovery_code"] = code
+	} else {
+		body["code"] = code
+	}
+	var raw json.RawMessage
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/login/mfa",
+		body:   body,
+	}, &raw)
+	if err != nil {
+		return nil, err
+	}
+	return c.startSession(raw)
+}
+
+func (c *Client) startSession(raw json.RawMessage) (*Session, error) {
+	session := &Session{}
+	if err := json.Unmarshal(raw, session); err != nil {
+		return nil, fmt.Errorf("client: failed to decode session: %w", err)
+	}
+	c.storeTokens(Tokens{AccessToken: session.Token, RefreshToken: session.RefreshToken})
+	return session, nil
+}
+
+// Refresh gets a new access token with the refresh token and returns it.
+func (c *Client) Refresh(ctx context.Context) (string, error) {
+	var payload struct {
+		Token string `json:"token"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/refresh",
+		auth:   authRefresh,
+	}, &payload)
+	if err != nil {
+		return "", err
+	}
+	c.storeTokens(Tokens{AccessToken: payload.Token})
+	return payload.Token, nil
+}
+
+// Logout revokes the refresh token and forgets the session.
+func (c *Client) Logout(ctx context.Context) error {
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/revoke",
+		auth:   authRefresh,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.accessToken, c.refreshToken = "", ""
+	onTokens := c.OnTokens
+	c.mu.Unlock()
+	if onTokens != nil {
+		onTokens(Tokens{})
+	}
+	return nil
+}
+
+func (c *Client) CurrentUser(ctx context.Context) (*User, error) {
+	user := &User{}
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/users/me",
+		auth:   authAccess,
+	}, user)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (c *Client) Organizations(ctx context.Context) ([]Organization, error) {
+	var orgs []Organization
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/organizations",
+		auth:   authAccess,
+	}, &orgs)
+	return orgs, err
+}
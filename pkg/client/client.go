@@ -0,0 +1,280 @@
+// Package client is a typed client for the Video Manager API, following the
+// OpenAPI document served at /api/openapi.json. It keeps the session tokens
+// and refreshes the access token before it expires.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RefreshMargin is how long before its expiry the access token gets refreshed.
+const RefreshMargin = time.Minute
+
+// ErrNoSession is returned by calls that need tokens the client doesn't have.
+var ErrNoSession = errors.New("client: not logged in")
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+	// OnTokens is called whenever the tokens change, to persist them.
+	OnTokens func(Tokens)
+}
+
+// Tokens are the credentials of a session.
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// New returns a client of the API at baseURL. httpClient may be nil.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// SetTokens resumes a session, for instance one saved by OnTokens.
+func (c *Client) SetTokens(tokens Tokens) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = tokens.AccessToken
+	c.refreshToken = tokens.RefreshToken
+}
+
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Tokens{AccessToken: c.accessToken, RefreshToken: c.refreshToken}
+}
+
+func (c *Client) storeTokens(tokens Tokens) {
+	c.mu.Lock()
+	c.accessToken = tokens.AccessToken
+	if tokens.RefreshToken != "" {
+		c.refreshToken = tokens.RefreshToken
+	}
+	stored := Tokens{AccessToken: c.accessToken, RefreshToken: c.refreshToken}
+	onTokens := c.OnTokens
+	c.mu.Unlock()
+	if onTokens != nil {
+		onTokens(stored)
+	}
+}
+
+// Error is a problem details response of the API.
+type Error struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail"`
+	Instance  string       `json:"instance"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id"`
+	Errors    []FieldError `json:"errors"`
+}
+
+type FieldError struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Detail)
+	for _, field := range e.Errors {
+		msg += fmt.Sprintf("; %s: %s", field.Field, field.Detail)
+	}
+	return msg
+}
+
+// IsCode reports whether err is an API error with the given code.
+func IsCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// auth selects the credentials a request is sent with.
+type auth int
+
+const (
+	authNone auth = iota
+	authAccess
+	authRefresh
+)
+
+// request describes a call. body is either JSON encoded or, when it's an
+// io.Reader, sent as is with contentType.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	auth        auth
+	body        any
+	contentType string
+}
+
+// do sends req and decodes a JSON response into out, which may be nil.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	res, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if out == nil {
+		_, err = io.Copy(io.Discard, res.Body)
+		return err
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send sends req and returns successful responses, the caller closes the body.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	var body io.Reader
+	contentType := req.contentType
+	var replayable []byte
+	switch value := req.body.(type) {
+	case nil:
+	case io.Reader:
+		body = value
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("client: failed to encode request: %w", err)
+		}
+		replayable, body, contentType = data, bytes.NewReader(data), "application/json"
+	}
+	if req.auth == authAccess {
+		if err := c.refreshIfExpiring(ctx); err != nil {
+			return nil, err
+		}
+	}
+	res, err := c.sendOnce(ctx, req, body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	// The access token can still be rejected, for instance after a key
+	// rotation. Requests that can be sent again are retried once.
+	if res.StatusCode == http.StatusUnauthorized && req.auth == authAccess && (req.body == nil || replayable != nil) {
+		apiErr := decodeError(res)
+		if apiErr.Code != "invalid_access_token" || c.Tokens().RefreshToken == "" {
+			return nil, apiErr
+		}
+		if _, err := c.Refresh(ctx); err != nil {
+			return nil, err
+		}
+		if replayable != nil {
+			body = bytes.NewReader(replayable)
+		}
+		res, err = c.sendOnce(ctx, req, body, contentType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, decodeError(res)
+	}
+	return res, nil
+}
+
+func (c *Client) sendOnce(ctx context.Context, req request, body io.Reader, contentType string) (*http.Response, error) {
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	tokens := c.Tokens()
+	switch req.auth {
+	case authAccess:
+		if tokens.AccessToken == "" {
+			return nil, ErrNoSession
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	case authRefresh:
+		if tokens.RefreshToken == "" {
+			return nil, ErrNoSession
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+tokens.RefreshToken)
+	}
+	return c.httpClient.Do(httpReq)
+}
+
+// decodeError reads the problem details of a failed response and closes it.
+func decodeError(res *http.Response) *Error {
+	defer res.Body.Close()
+	apiErr := &Error{}
+	data, _ := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err := json.Unmarshal(data, apiErr); err != nil || apiErr.Status == 0 {
+		apiErr = &Error{
+			Status: res.StatusCode,
+			Title:  http.StatusText(res.StatusCode),
+			Detail: strings.TrimSpace(string(data)),
+		}
+	}
+	if apiErr.Code == "" {
+		apiErr.Code = strings.ReplaceAll(strings.ToLower(http.StatusText(res.StatusCode)), " ", "_")
+	}
+	return apiErr
+}
+
+// refreshIfExpiring refreshes the access token when it's about to expire.
+func (c *Client) refreshIfExpiring(ctx context.Context) error {
+	tokens := c.Tokens()
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		return nil
+	}
+	expiresAt, ok := tokenExpiry(tokens.AccessToken)
+	if !ok || time.Until(expiresAt) > RefreshMargin {
+		return nil
+	}
+	_, err := c.Refresh(ctx)
+	return err
+}
+
+// tokenExpiry reads the exp claim of a JWT. The signature isn't checked, the
+// server does that.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.ExpiresAt, 0), true
+}
@@ -0,0 +1,53 @@
+package client
+
+import "time"
+
+type User struct {
+	ID                  string     `json:"id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	Email               string     `json:"email"`
+	EmailVerified       bool       `json:"email_verified"`
+	EmailVerifiedAt     *time.Time `json:"email_verified_at,omitempty"`
+	MFAEnabled          bool       `json:"mfa_enabled"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	Role                string     `json:"role"`
+	Disabled            bool       `json:"disabled"`
+}
+
+// Session is what a successful login returns.
+type Session struct {
+	User         User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Video is a video of an organization library. UserID is empty once the
+// account that uploaded it is deleted.
+type Video struct {
+	ID             string    `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	ThumbnailURL   string    `json:"thumbnail_url"`
+	VideoURL       string    `json:"video_url"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description"`
+	UserID         string    `json:"user_id"`
+	OrganizationID string    `json:"organization_id"`
+}
+
+// VideoDraft creates a video. Without an organization it goes to the user's
+// personal one.
+type VideoDraft struct {
+	Title          string `json:"title"`
+	Description    string `json:"description,omitempty"`
+	OrganizationID string `json:"organization_id,omitempty"`
+}
+
+type Organization struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+)
+
+// Media types accepted by the uploads.
+const (
+	MediaTypeMP4  = "video/mp4"
+	MediaTypePNG  = "image/png"
+	MediaTypeJPEG = "image/jpeg"
+)
+
+func (c *Client) CreateVideo(ctx context.Context, draft VideoDraft) (*Video, error) {
+	video := &Video{}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/videos",
+		auth:   authAccess,
+		body:   draft,
+	}, video)
+	if err != nil {
+		return nil, err
+	}
+	return video, nil
+}
+
+// ListVideos returns the videos of an organization, or of every organization
+// of the user when organizationID is empty.
+func (c *Client) ListVideos(ctx context.Context, organizationID string) ([]Video, error) {
+	query := url.Values{}
+	if organizationID != "" {
+		query.Set("organization_id", organizationID)
+	}
+	var videos []Video
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/videos",
+		query:  query,
+		auth:   authAccess,
+	}, &videos)
+	return videos, err
+}
+
+func (c *Client) GetVideo(ctx context.Context, videoID string) (*Video, error) {
+	video := &Video{}
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/videos/" + url.PathEscape(videoID),
+		auth:   authAccess,
+	}, video)
+	if err != nil {
+		return nil, err
+	}
+	return video, nil
+}
+
+func (c *Client) DeleteVideo(ctx context.Context, videoID string) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   "/api/videos/" + url.PathEscape(videoID),
+		auth:   authAccess,
+	}, nil)
+}
+
+// UploadVideo uploads the MP4 media of a video from r, which is streamed.
+// Wrap r to follow the progress.
+func (c *Client) UploadVideo(ctx context.Context, videoID, filename string, r io.Reader) (*Video, error) {
+	return c.upload(ctx, "/api/video_upload/"+url.PathEscape(videoID), "video", filename, MediaTypeMP4, r)
+}
+
+// UploadThumbnail uploads a PNG or JPEG thumbnail of a video.
+func (c *Client) UploadThumbnail(ctx context.Context, videoID, filename, mediaType string, r io.Reader) (*Video, error) {
+	return c.upload(ctx, "/api/thumbnail_upload/"+url.PathEscape(videoID), "thumbnail", filename, mediaType, r)
+}
+
+// upload streams r as the only file of a multipart form.
+func (c *Client) upload(ctx context.Context, path, field, filename, mediaType string, r io.Reader) (*Video, error) {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, field, filename))
+		header.Set("Content-Type", mediaType)
+		part, err := form.CreatePart(header)
+		if err == nil {
+			_, err = io.Copy(part, r)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+	video := &Video{}
+	err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        path,
+		auth:        authAccess,
+		body:        io.Reader(body),
+		contentType: form.FormDataContentType(),
+	}, video)
+	// Stops the goroutine when the request failed before reading everything.
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+	return video, nil
+}